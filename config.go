@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/audio"
+	"github.com/ctdk/morseudar/internal/config"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/stats"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+type ConfigCommand struct {
+	Show ConfigShowCommand `command:"show" description:"Print the settings a practice session would use with the other options given, and where each one came from."`
+}
+
+// ConfigShowCommand takes the same options as practice, so a practice command
+// line can be checked as is. Only the text file matters out of the material.
+type ConfigShowCommand struct {
+	PracticeSettings
+	PracticeMaterial
+}
+
+const defaultMode = "topwords"
+
+func configPath() string {
+	return filepath.Join(stats.DataDir(), config.FileName)
+}
+
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range cfg.ModeNames() {
+		if _, err := morse.ModeFromName(name); err != nil {
+			return nil, fmt.Errorf("error in config file %s: [modes.%s]: %w", configPath(), name, err)
+		}
+	}
+	if cfg.Mode != "" {
+		if _, err := morse.ModeFromName(cfg.Mode); err != nil {
+			return nil, fmt.Errorf("error in config file %s: %w", configPath(), err)
+		}
+	}
+	return cfg, nil
+}
+
+// effective are the settings a session actually runs with after everything's
+// been taken into account, along with where each one came from.
+type effective struct {
+	Mode morse.MorseMode
+	Wpm int
+	Farnsworth int
+	Frequency int
+	TopWordNum int
+	Costs compare.Costs
+	Effects audio.Effects
+	shown []shownSetting
+}
+
+type shownSetting struct {
+	name string
+	value string
+	from string
+}
+
+type candidate[T comparable] struct {
+	from string
+	v T
+}
+
+// pick returns the first of the candidates that's actually set, or def if none
+// of them are, and notes where it came from for `config show`.
+func pick[T comparable](e *effective, name string, def T, defDesc string, cands ...candidate[T]) T {
+	var zero T
+	for _, c := range cands {
+		if c.v != zero {
+			e.shown = append(e.shown, shownSetting{name, fmt.Sprint(c.v), c.from})
+			return c.v
+		}
+	}
+	if defDesc == "" {
+		defDesc = fmt.Sprint(def)
+	}
+	e.shown = append(e.shown, shownSetting{name, defDesc, "default"})
+	return def
+}
+
+// resolveSettings works out the settings for a session. Options given on the
+// command line win, then the profile's settings, then the config file's
+// settings for the mode, then the config file's general settings, and finally
+// the built in defaults. The practice settings' mode is filled in with the
+// result.
+func resolveSettings(ps *PracticeSettings, text string, prof stats.Settings, cfg *config.Config) (*effective, error) {
+	e := new(effective)
+
+	// Without a mode anywhere, giving a text file is a pretty good hint
+	// that it should be used.
+	def := defaultMode
+	if text != "" {
+		def = "text"
+	}
+	ps.Mode = pick(e, "mode", def, "", candidate[string]{"command line", strings.ToLower(ps.Mode)}, candidate[string]{"profile", prof.Mode}, candidate[string]{"config", cfg.Mode})
+	var err error
+	if e.Mode, err = morse.ModeFromName(ps.Mode); err != nil {
+		return nil, err
+	}
+
+	// The config file's settings for the mode, with the general ones
+	// filling in whatever the mode leaves out. The mode's own section is
+	// only looked at to say where each one came from.
+	cs := cfg.For(ps.Mode)
+	ms := cfg.Modes[ps.Mode]
+	cfgSrc := func(inMode bool) string {
+		if inMode {
+			return fmt.Sprintf("config [modes.%s]", ps.Mode)
+		}
+		return "config"
+	}
+	// Anything given on the command line is used even if it's 0, since
+	// that's how Farnsworth timing gets turned off, for instance.
+	ints := func(name string, flag *int, profile int, conf int, inMode bool, def int, defDesc string) int {
+		if flag != nil {
+			v := fmt.Sprint(*flag)
+			if *flag == def && defDesc != "" {
+				v = defDesc
+			}
+			e.shown = append(e.shown, shownSetting{name, v, "command line"})
+			return *flag
+		}
+		return pick(e, name, def, defDesc, candidate[int]{"profile", profile}, candidate[int]{cfgSrc(inMode), conf})
+	}
+	e.Wpm = ints("wpm", ps.Wpm, prof.Wpm, cs.Wpm, ms.Wpm != 0, morse.DefaultWPM, "")
+	e.Farnsworth = ints("farnsworth", ps.Farnsworth, prof.Farnsworth, cs.Farnsworth, ms.Farnsworth != 0, 0, "off")
+	e.Frequency = ints("frequency", ps.Frequency, prof.Frequency, cs.Frequency, ms.Frequency != 0, morse.DefaultFrequency, "")
+	e.TopWordNum = ints("top_word_num", ps.TopWordNum, prof.TopWordNum, cs.TopWordNum, ms.TopWordNum != 0, 0, "all")
+
+	// these are only in the config file
+	e.Costs.Replace = ints("compare.replace_cost", nil, 0, cs.Compare.ReplaceCost, ms.Compare.ReplaceCost != 0, compare.DefaultCosts.Replace, "")
+	e.Costs.Insert = ints("compare.insert_cost", nil, 0, cs.Compare.InsertCost, ms.Compare.InsertCost != 0, compare.DefaultCosts.Insert, "")
+	e.Costs.Delete = ints("compare.delete_cost", nil, 0, cs.Compare.DeleteCost, ms.Compare.DeleteCost != 0, compare.DefaultCosts.Delete, "")
+	e.Effects.Volume = pick(e, "audio.volume", audio.DefaultEffects.Volume, "", candidate[float64]{cfgSrc(ms.Audio.Volume != 0), cs.Audio.Volume})
+	e.Effects.Decay = ints("audio.decay", nil, 0, cs.Audio.Decay, ms.Audio.Decay != 0, audio.DefaultEffects.Decay, "")
+
+	return e, nil
+}
+
+func showConfig(uStats *stats.UserStats, csc *ConfigShowCommand) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	e, err := resolveSettings(&csc.PracticeSettings, csc.Text, uStats.Settings, cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Config file: %s", configPath())
+	if _, err := os.Stat(configPath()); os.IsNotExist(err) {
+		fmt.Print(" (not there, so everything's from the defaults)")
+	}
+	fmt.Print("\n\n")
+
+	tw := new(tabwriter.Writer)
+	tw.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "Setting\tValue\tFrom")
+	for _, s := range e.shown {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.name, s.value, s.from)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cw
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gopxl/beep/wav"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	msg, err := Encode("  CQ   de ~sk~ ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Text() != "cq de sk" {
+		t.Errorf("unexpected text '%s'", msg.Text())
+	}
+	if want := "-.-. --.- / -.. . / ...-.-"; msg.String() != want {
+		t.Errorf("expected '%s', got '%s'", want, msg.String())
+	}
+
+	msg, err = Encode("50% off")
+	var uce *UnknownCharsError
+	if !errors.As(err, &uce) || !reflect.DeepEqual(uce.Chars, []rune{'%'}) {
+		t.Errorf("expected an UnknownCharsError for '%%', got %v", err)
+	}
+	if msg.Text() != "50 off" {
+		t.Errorf("the rest of the message should still have been encoded, got '%s'", msg.Text())
+	}
+
+	text, err := Decode(msg.String())
+	if err != nil || text != "50 off" {
+		t.Errorf("decoding gave '%s', %v", text, err)
+	}
+}
+
+func TestTiming(t *testing.T) {
+	if _, err := NewTiming(0, 0); err == nil {
+		t.Error("0 wpm should have been rejected")
+	}
+
+	tm, err := NewTiming(20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm.Dit() != 60 * time.Millisecond || tm.Dah() != 3 * tm.Dit() || tm.LetterGap() != 3 * tm.Dit() || tm.WordGap() != 7 * tm.Dit() {
+		t.Errorf("unexpected timing at 20 wpm: %s %s %s %s", tm.Dit(), tm.Dah(), tm.LetterGap(), tm.WordGap())
+	}
+
+	// PARIS is the standard word, 50 dits long
+	paris, _ := Encode("paris")
+	if d := tm.Duration(paris); d != 50 * tm.Dit() {
+		t.Errorf("'paris' should take 50 dits, took %s", d)
+	}
+
+	// Farnsworth only stretches the gap between words
+	fm, _ := NewTiming(20, 10)
+	if fm.Dit() != tm.Dit() || fm.WordGap() != 2 * tm.WordGap() {
+		t.Errorf("unexpected Farnsworth timing: dit %s, word gap %s", fm.Dit(), fm.WordGap())
+	}
+
+	e, _ := Encode("e")
+	want := []Element{{true, tm.Dit()}, {false, tm.Dit()}, {false, tm.WordGap() - tm.Dit()}}
+	if els := tm.Elements(e); !reflect.DeepEqual(els, want) {
+		t.Errorf("expected elements %v for 'e', got %v", want, els)
+	}
+}
+
+func TestRender(t *testing.T) {
+	paris, _ := Encode("paris")
+	s := Settings{WPM: 20, Volume: 0.5}
+	samples, err := Render(s, paris)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm, _ := s.Timing()
+	if want := int(tm.Duration(paris).Seconds() * float64(SampleRate())); len(samples) != want {
+		t.Errorf("expected %d samples, got %d", want, len(samples))
+	}
+	loudest := 0.0
+	for _, smp := range samples {
+		loudest = max(loudest, math.Abs(smp))
+	}
+	if loudest > 0.5 || loudest < 0.45 {
+		t.Errorf("samples at volume 0.5 peaked at %f", loudest)
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "paris.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err = WriteWAV(f, s, paris); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	st, format, err := wav.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(format.SampleRate) != SampleRate() || st.Len() != len(samples) {
+		t.Errorf("WAV file should have had %d samples at %d Hz, had %d at %d Hz", len(samples), SampleRate(), st.Len(), format.SampleRate)
+	}
+}
+
+func TestScore(t *testing.T) {
+	if s := Score("CQ DE W1AW", "cq de w1aw"); s != 1 {
+		t.Errorf("a perfect copy should score 1, got %f", s)
+	}
+	if s := Score("cq de w1aw", ""); s != 0 {
+		t.Errorf("an empty copy should score 0, got %f", s)
+	}
+
+	if _, err := NewScorer(Costs{Insert: -1}); err == nil {
+		t.Error("negative costs should have been rejected")
+	}
+	strict, _ := NewScorer(Costs{Delete: 2})
+	if strict.Score("w1aw", "w1a") >= Score("w1aw", "w1a") {
+		t.Error("dropped characters should cost more with a higher delete cost")
+	}
+
+	scores := strict.ScoreBlock([]string{"cq cq", "de w1aw"}, "cq cq de w1aw")
+	if len(scores) != 2 || scores[0].Copied != "cq cq" || scores[1].Score != 1 {
+		t.Errorf("unexpected block scores %+v", scores)
+	}
+}
+
+func ExampleEncode() {
+	msg, err := Encode("cq de w1aw")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(msg)
+	// Output: -.-. --.- / -.. . / .-- .---- .- .--
+}
+
+func ExampleTiming_Duration() {
+	t, _ := NewTiming(20, 0)
+	msg, _ := Encode("paris")
+	fmt.Println(t.Duration(msg))
+	// Output: 3s
+}
+
+func ExampleScore() {
+	fmt.Printf("%.2f\n", Score("cq de w1aw", "cq de w1aq"))
+	// Output: 0.90
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cw is the public face of morseudar, for using its Morse code in other
+// programs, like a chat bot that sends practice lines or a contest logger that
+// plays back callsigns. It can encode text as Morse code and decode it again,
+// work out the timing of a message at a given speed, render it to PCM samples
+// or a WAV file, and score a copy of it the same way a practice session does.
+//
+// A quick example:
+//
+//	msg, err := cw.Encode("cq cq de w1aw")
+//	if err != nil {
+//		// there were characters without a Morse code, which were
+//		// left out of msg
+//	}
+//	fmt.Println(msg) // -.-. --.- / -.-. --.- / -.. . / .-- .---- .- .--
+//
+//	s := cw.Settings{WPM: 20, Farnsworth: 10}
+//	f, _ := os.Create("cq.wav")
+//	err = cw.WriteWAV(f, s, msg)
+//
+//	score := cw.Score("cq de w1aw", "cq de w1aq") // 0.9
+//
+// # Versioning
+//
+// Everything exported from this package is covered by semantic versioning from
+// morseudar 1.0.0 on: within a major version, nothing here will be removed or
+// change its meaning, although things may be added. Anything that has to break
+// will wait for a new major version, with a new import path to match. The
+// packages under internal/ make no such promise, and can't be imported from
+// outside morseudar anyway.
+//
+// Until then, while morseudar is at 0.x, the minor version plays the part of
+// the major one: anything that breaks code using this package only happens in
+// a release that bumps the minor version, like 0.3.0 to 0.4.0, and says so in
+// its release notes. Patch releases never break anything.
+//
+// The one exception is scoring. Score and Scorer are meant to agree with
+// morseudar itself, so if the way copies are scored gets better, the numbers
+// they return may change in a minor version. The range of scores, 0 to 1,
+// won't.
+package cw
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cw
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"strings"
+)
+
+// Message is some text encoded as Morse code.
+type Message struct {
+	ms morsestrings.MorseString
+}
+
+// UnknownCharsError is returned by Encode when the text had characters in it
+// that don't have a Morse code.
+type UnknownCharsError struct {
+	// Chars are the unknown characters, each one once, in the order they
+	// turned up.
+	Chars []rune
+}
+
+func (e *UnknownCharsError) Error() string {
+	quoted := make([]string, len(e.Chars))
+	for i, c := range e.Chars {
+		quoted[i] = fmt.Sprintf("%q", c)
+	}
+	return fmt.Sprintf("no Morse code for %s", strings.Join(quoted, ", "))
+}
+
+// Encode turns text into Morse code. Case doesn't matter. A word wrapped in
+// tildes, like ~sk~ or ~ar~, is sent as a prosign: its characters run together
+// without the usual gaps between them.
+//
+// If there are any characters without a Morse code, they're left out and
+// Encode returns a *UnknownCharsError along with the rest of the message, so
+// it can still be used if that's good enough.
+func Encode(text string) (Message, error) {
+	ms, unknown := morsestrings.Encode(text)
+	msg := Message{ms: ms}
+	if len(unknown) > 0 {
+		return msg, &UnknownCharsError{Chars: unknown}
+	}
+	return msg, nil
+}
+
+// Decode turns dots and dashes back into text. Characters are separated by
+// spaces, and words by slashes, the way Message.String writes them. Prosigns
+// come back wrapped in tildes, like ~sk~. Characters wrapped in tildes, like
+// ~.- .-.~, are always taken as a prosign, and so is a single pattern wrapped
+// in tildes, like ~.-.-.~, or one that isn't a character, as long as it's a
+// common prosign. Any other pattern is an error.
+func Decode(dotdash string) (string, error) {
+	return morsestrings.DecodeDotDash(dotdash)
+}
+
+// Alphabet returns the characters Encode knows about, with their Morse codes
+// as dots and dashes. The map is a copy, so changing it doesn't change what
+// Encode does.
+func Alphabet() map[rune]string {
+	a := make(map[rune]string, len(morsestrings.Alphabet))
+	for r, mc := range morsestrings.Alphabet {
+		a[r] = string(mc)
+	}
+	return a
+}
+
+// String returns the message as dots and dashes, with spaces between
+// characters and slashes between words.
+func (m Message) String() string {
+	return m.ms.DotDashString()
+}
+
+// Text returns the text that was encoded, in lower case and without the
+// characters that couldn't be.
+func (m Message) Text() string {
+	return m.ms.RawString()
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cw
+
+import (
+	"github.com/ctdk/morseudar/internal/audio"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"io"
+)
+
+// Settings describe how rendered Morse code sounds. Zero values get the same
+// defaults morseudar uses.
+type Settings struct {
+	// WPM is the speed in words per minute, 10 by default.
+	WPM int
+	// Farnsworth is the speed the gaps between words are sent at, as
+	// with NewTiming. 0 means no Farnsworth spacing.
+	Farnsworth int
+	// Frequency is the pitch of the beeps in Hz, 700 by default.
+	Frequency float64
+	// Volume is from 0 to 1, 1 by default.
+	Volume float64
+}
+
+// SampleRate returns the sample rate of rendered audio, in samples per second.
+func SampleRate() int {
+	return audio.SampleRate()
+}
+
+// Timing returns the timing for the settings' speeds.
+func (s Settings) Timing() (Timing, error) {
+	s = s.withDefaults()
+	return NewTiming(s.WPM, s.Farnsworth)
+}
+
+func (s Settings) withDefaults() Settings {
+	if s.WPM == 0 {
+		s.WPM = morse.DefaultWPM
+	}
+	if s.Frequency == 0 {
+		s.Frequency = morse.DefaultFrequency
+	}
+	return s
+}
+
+func (s Settings) audio() (*audio.MorseAudio, error) {
+	s = s.withDefaults()
+	if _, err := s.Timing(); err != nil {
+		return nil, err
+	}
+	farn := s.Farnsworth
+	if farn >= s.WPM {
+		farn = 0
+	}
+	return audio.NewMorseAudioEffects(s.Frequency, s.WPM, farn, audio.Effects{Volume: s.Volume})
+}
+
+func paragraph(msgs []Message) [][]morsestrings.MorseString {
+	para := make([]morsestrings.MorseString, len(msgs))
+	for i, m := range msgs {
+		para[i] = m.ms
+	}
+	return [][]morsestrings.MorseString{para}
+}
+
+// Render renders the messages, one after the other, to mono PCM samples from
+// -1 to 1 at SampleRate().
+func Render(s Settings, msgs ...Message) ([]float64, error) {
+	ma, err := s.audio()
+	if err != nil {
+		return nil, err
+	}
+	stereo, err := ma.Samples(paragraph(msgs))
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]float64, len(stereo))
+	for i, st := range stereo {
+		samples[i] = st[0]
+	}
+	return samples, nil
+}
+
+// WriteWAV renders the messages, one after the other, to w as a 16 bit stereo
+// WAV file.
+func WriteWAV(w io.WriteSeeker, s Settings, msgs ...Message) error {
+	ma, err := s.audio()
+	if err != nil {
+		return err
+	}
+	return ma.Render(w, paragraph(msgs))
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cw
+
+import (
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"time"
+)
+
+// Costs are how much each kind of mistake counts against a copy. Zero values
+// get the usual cost of 1.
+type Costs struct {
+	// Replace is for a wrong character.
+	Replace int
+	// Insert is for an extra character that wasn't sent.
+	Insert int
+	// Delete is for a character that was sent but not copied.
+	Delete int
+}
+
+// Scorer scores copies of what was sent, giving partial credit rather than
+// just right or wrong.
+type Scorer struct {
+	c *compare.Comparator
+}
+
+// LineScore is how well one line of a block was copied.
+type LineScore struct {
+	Sent string
+	Copied string
+	Score float64
+}
+
+// NewScorer makes a Scorer with the given costs. Negative costs are an error.
+func NewScorer(costs Costs) (*Scorer, error) {
+	c, err := compare.NewWithCosts(compare.Costs{Replace: costs.Replace, Insert: costs.Insert, Delete: costs.Delete})
+	if err != nil {
+		return nil, err
+	}
+	return &Scorer{c: c}, nil
+}
+
+// Score scores a copy of one line with the usual costs, from 0 for nothing
+// right to 1 for a perfect copy. Case doesn't matter.
+func Score(sent string, copied string) float64 {
+	s, _ := NewScorer(Costs{})
+	return s.Score(sent, copied)
+}
+
+// Score scores a copy of one line, from 0 for nothing right to 1 for a perfect
+// copy. Case doesn't matter.
+func (s *Scorer) Score(sent string, copied string) float64 {
+	return s.c.Compare(sent, copied, time.Now(), 1).Percentage
+}
+
+// ScoreBlock scores a copy of a whole block of lines sent at once, the way a
+// copy exam would be. The copy doesn't need to be broken up into lines; its
+// words are matched up with the lines they were most likely copied from.
+func (s *Scorer) ScoreBlock(sent []string, copied string) []LineScore {
+	ab := s.c.CompareBlock(sent, copied, time.Now(), 1)
+	scores := make([]LineScore, len(ab))
+	for i, a := range ab {
+		scores[i] = LineScore{Sent: a.Original, Copied: a.Response, Score: a.Percentage}
+	}
+	return scores
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cw
+
+import (
+	"errors"
+	"github.com/ctdk/morseudar/internal/audio"
+	"time"
+)
+
+// Timing is how long each part of Morse code lasts at a particular speed. It's
+// based on the standard word PARIS, which is 50 dits long including the space
+// after it.
+type Timing struct {
+	t audio.Timing
+}
+
+// Element is one beep, or one silence, in a message.
+type Element struct {
+	On bool
+	Duration time.Duration
+}
+
+// NewTiming works out the timing for the given speed in words per minute. If
+// farnsworth is given, the characters are still sent at wpm, but the spaces
+// between words are stretched out as if they were sent at farnsworth words per
+// minute. A farnsworth speed that isn't slower than wpm is ignored, and 0
+// turns it off.
+func NewTiming(wpm int, farnsworth int) (Timing, error) {
+	if wpm <= 0 || farnsworth < 0 {
+		return Timing{}, errors.New("speeds have to be positive")
+	}
+	if farnsworth >= wpm {
+		farnsworth = 0
+	}
+	return Timing{t: audio.NewTiming(wpm, farnsworth)}, nil
+}
+
+// Dit is the length of a dit, and of the silence between the dits and dahs in
+// a character.
+func (t Timing) Dit() time.Duration {
+	return t.t.Dit()
+}
+
+// Dah is the length of a dah.
+func (t Timing) Dah() time.Duration {
+	return t.t.Dash()
+}
+
+// LetterGap is the silence between the characters in a word.
+func (t Timing) LetterGap() time.Duration {
+	return t.t.LetterSep()
+}
+
+// WordGap is the silence between words.
+func (t Timing) WordGap() time.Duration {
+	return t.t.WordSep()
+}
+
+// Elements lays out the beeps and silences of a message, ending with the gap
+// after the last word. This is exactly what Render and WriteWAV play, so it
+// can be used to play a message some other way, like with a browser's Web
+// Audio, or to key a transmitter.
+func (t Timing) Elements(m Message) []Element {
+	// Messages only ever hold real Morse characters, so this can't fail.
+	els, _ := t.t.Elements(m.ms)
+	out := make([]Element, len(els))
+	for i, e := range els {
+		out[i] = Element{On: e.On, Duration: e.Dur}
+	}
+	return out
+}
+
+// Duration is how long a message takes to send, including the gap after the
+// last word.
+func (t Timing) Duration(m Message) time.Duration {
+	var d time.Duration
+	for _, e := range t.Elements(m) {
+		d += e.Duration
+	}
+	return d
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/ctdk/morseudar/internal/decoder"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"io"
+	"os"
+	"strings"
+)
+
+type DecodeCommand struct {
+	WAV string `long:"wav" description:"Decode Morse code from a WAV file instead, like one written by the render command or a recording off the air. The speed and the pitch of the tone are worked out from the audio."`
+	Frequency int `short:"f" long:"frequency" description:"Frequency in Hz of the tone to decode with --wav, if there's more than one and the loudest isn't the one you want."`
+	Args struct {
+		Code []string `positional-arg-name:"CODE" description:"Dots and dashes to decode, with spaces between characters and '/' between words, like '.... .. / - .... . .-. .'. Prosigns are wrapped in tildes, like '~... -.-~'. If there aren't any, each line of standard input is decoded."`
+	} `positional-args:"yes"`
+}
+
+func decodeMorse(dc *DecodeCommand) error {
+	if dc.WAV != "" {
+		if len(dc.Args.Code) > 0 {
+			return errors.New("Dots and dashes can't be decoded along with a WAV file.")
+		}
+		return decodeWAV(dc)
+	}
+	if dc.Frequency != 0 {
+		return errors.New("-f/--frequency only makes sense with --wav.")
+	}
+	return convertLines(dc.Args.Code, os.Stdin, os.Stdout, "decoded", morsestrings.DecodeDotDash)
+}
+
+// decodeWAV writes the decoded text to stdout, and what it was sent with to
+// stderr, so the text can be piped somewhere on its own.
+func decodeWAV(dc *DecodeCommand) error {
+	if dc.Frequency < 0 {
+		return errors.New("The frequency can't be negative.")
+	}
+	f, err := os.Open(dc.WAV)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	res, err := decoder.DecodeWAV(f, decoder.Settings{Frequency: float64(dc.Frequency)})
+	if err != nil {
+		return fmt.Errorf("Unable to decode %s: %w", dc.WAV, err)
+	}
+	fmt.Println(res.Text)
+	speed := fmt.Sprintf("%d wpm", res.WPM)
+	if res.Farnsworth != 0 {
+		speed = fmt.Sprintf("%d wpm with Farnsworth spacing at %d wpm", res.WPM, res.Farnsworth)
+	}
+	fmt.Fprintf(os.Stderr, "Decoded at about %s, with a %.0f Hz tone.\n", speed, res.Frequency)
+	return nil
+}
+
+// convertLines runs conv on the arguments, all together, or else on each line
+// of in, writing what comes back to out. If a line can't be converted, what
+// went wrong is printed to stderr, and the rest of them are still converted
+// so one bad line doesn't hold up a whole pipeline. If conv returns some
+// output along with the error, that's written too. Any problems turn into an
+// error at the end.
+func convertLines(args []string, in io.Reader, out io.Writer, what string, conv func(string) (string, error)) error {
+	var bad int
+	convert := func(line string, where string) {
+		res, err := conv(line)
+		if err != nil {
+			bad++
+			fmt.Fprintf(os.Stderr, "%s%s\n", where, err)
+			if res == "" {
+				return
+			}
+		}
+		fmt.Fprintln(out, res)
+	}
+
+	if len(args) > 0 {
+		convert(strings.Join(args, " "), "")
+	} else {
+		scanner := bufio.NewScanner(in)
+		for l := 1; scanner.Scan(); l++ {
+			convert(scanner.Text(), fmt.Sprintf("line %d: ", l))
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	if bad > 0 {
+		if len(args) > 0 {
+			return fmt.Errorf("the text couldn't be fully %s", what)
+		}
+		return fmt.Errorf("%d %s couldn't be fully %s", bad, plural(bad, "line"), what)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"github.com/ctdk/morseudar/cw"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"os"
+)
+
+type EncodeCommand struct {
+	Plain bool `long:"plain" description:"Don't wrap prosigns in tildes. Prosigns then run their characters together, and the ones that are also punctuation, like ~ar~ and +, decode as the punctuation."`
+	Args struct {
+		Text []string `positional-arg-name:"TEXT" description:"Text to encode. Words wrapped in tildes, like ~sk~, are prosigns. If there isn't any, each line of standard input is encoded."`
+	} `positional-args:"yes"`
+}
+
+func encodeMorse(ec *EncodeCommand) error {
+	return convertLines(ec.Args.Text, os.Stdin, os.Stdout, "encoded", func(text string) (string, error) {
+		return encodeLine(text, ec.Plain)
+	})
+}
+
+// encodeLine turns a line of text into dots and dashes. Characters without a
+// Morse code are left out, and returned as an error along with the rest.
+func encodeLine(text string, plain bool) (string, error) {
+	ms, unknown := morsestrings.Encode(text)
+	var dotdash string
+	if plain {
+		dotdash = ms.DotDashString()
+	} else {
+		dotdash = ms.MarkedDotDashString()
+	}
+
+	if len(unknown) > 0 {
+		return dotdash, &cw.UnknownCharsError{Chars: unknown}
+	}
+	return dotdash, nil
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/report"
+	"github.com/ctdk/morseudar/internal/stats"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type ExportCommand struct {
+	Format string `long:"format" description:"Format to export the statistics in." choice:"json" choice:"csv" default:"json"`
+	Output string `long:"output" description:"File to write the exported statistics to. Defaults to standard output."`
+}
+
+type ImportCommand struct {
+	Format string `long:"format" description:"Format of the file being imported. Defaults to guessing from the file's extension, or JSON if that doesn't work." choice:"json" choice:"csv"`
+	Force bool `long:"force" description:"Replace existing statistics with the imported ones."`
+	Args struct {
+		File string `positional-arg-name:"FILE" description:"File to import. Use '-' to read from standard input."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+type StatsCommand struct {
+	Show StatsShowCommand `command:"show" description:"Print the summary of each session, your practice streak, and your goals."`
+	Report ReportCommand `command:"report" description:"Print a progress report with trends, charts, personal bests and regressions."`
+	Merge StatsMergeCommand `command:"merge" description:"Merge stat files, like ones from different computers, dropping duplicate sessions."`
+}
+
+type StatsShowCommand struct {}
+
+type ReportCommand struct {
+	Window int `long:"window" description:"Number of sessions to average over for moving averages and spotting regressions." default:"5"`
+	Format string `long:"format" description:"Format of the report. 'html' makes a single self-contained HTML file with charts, a character heat map, and practice calendars." choice:"text" choice:"html" default:"text"`
+	Output string `long:"output" description:"File to write the report to. Defaults to standard output."`
+	Chars bool `long:"chars" description:"Just report how often each character was missed over the last --days days, worst first."`
+	Days int `long:"days" description:"Number of days to look back over for --chars." default:"30"`
+}
+
+type StatsMergeCommand struct {
+	Output string `long:"output" description:"File to write just the merged files to, replacing anything already in it. Defaults to merging them into your own statistics."`
+	Args struct {
+		Files []string `positional-arg-name:"FILE" description:"Stat files to merge." required:"1"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func runStatsCommand(uStats *stats.UserStats, name string, sc *StatsCommand) error {
+	switch name {
+	case "show":
+		// The report is more useful, but sometimes you just want the
+		// raw summaries. The answers have the speeds they were sent at.
+		if err := uStats.LoadAnswers(); err != nil {
+			return err
+		}
+		for _, st := range uStats.Summaries {
+			fmt.Println(st)
+		}
+		uStats.WriteProgress(os.Stdout, time.Now(), false)
+	case "report":
+		return writeReport(uStats, &sc.Report)
+	case "merge":
+		return mergeStats(uStats, &sc.Merge)
+	}
+	return nil
+}
+
+func exportStats(uStats *stats.UserStats, ec *ExportCommand) error {
+	if err := uStats.LoadAnswers(); err != nil {
+		return err
+	}
+	return writeOutput(ec.Output, func(w io.Writer) error {
+		if ec.Format == "csv" {
+			return uStats.ExportCSV(w)
+		}
+		return uStats.ExportJSON(w)
+	})
+}
+
+// writeOutput has write write to the output file, or to stdout if there isn't
+// one or it's "-". The file's closed before returning, and an error closing it
+// counts, since that can be when a full disk finally shows up.
+func writeOutput(output string, write func(w io.Writer) error) error {
+	if output == "" || output == "-" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err = write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func importStats(uStats *stats.UserStats, ic *ImportCommand) error {
+	if len(uStats.Summaries) > 0 && !ic.Force {
+		return fmt.Errorf("there are already %d sessions saved in %s. Use --force to replace them with the imported statistics.", len(uStats.Summaries), uStats.SavePath())
+	}
+
+	var r io.Reader = os.Stdin
+	if ic.Args.File != "-" {
+		f, err := os.Open(ic.Args.File)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	format := ic.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(ic.Args.File)), ".")
+	}
+
+	var imported *stats.UserStats
+	var err error
+	if format == "csv" {
+		imported, err = stats.ImportCSV(r)
+	} else {
+		imported, err = stats.ImportJSON(r)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to import %s: %w", ic.Args.File, err)
+	}
+
+	// The settings belong to the profile, not the statistics, and the goals
+	// are kept too unless some were imported along with them.
+	imported.Settings = uStats.Settings
+	if imported.Goals == nil {
+		imported.Goals = uStats.Goals
+	}
+	if err = imported.Replace(uStats.SavePath()); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d sessions into %s.\n", len(imported.Summaries), uStats.SavePath())
+	return nil
+}
+
+func writeReport(uStats *stats.UserStats, rc *ReportCommand) error {
+	if err := uStats.LoadAnswers(); err != nil {
+		return err
+	}
+	return writeOutput(rc.Output, func(w io.Writer) error {
+		if rc.Chars {
+			rates, err := uStats.CharErrorRates(time.Now().AddDate(0, 0, -rc.Days))
+			if err != nil {
+				return err
+			}
+			return report.WriteCharErrors(w, rates, rc.Days)
+		}
+
+		r := report.New(uStats, rc.Window)
+		if rc.Format == "html" {
+			return r.WriteHTML(w)
+		}
+		return r.WriteText(w)
+	})
+}
+
+func mergeStats(uStats *stats.UserStats, mc *StatsMergeCommand) error {
+	merged, dups, err := stats.MergeFiles(mc.Args.Files...)
+	if err != nil {
+		return err
+	}
+
+	if mc.Output != "" {
+		if err = merged.Replace(mc.Output); err != nil {
+			return err
+		}
+		fmt.Printf("Merged %d sessions from %d files into %s, dropping %d duplicates.\n", len(merged.Summaries), len(mc.Args.Files), mc.Output, dups)
+		return nil
+	}
+
+	before := len(uStats.Summaries)
+	uStats.Merge(merged)
+	if err = uStats.Save(); err != nil {
+		return err
+	}
+	added := len(uStats.Summaries) - before
+	fmt.Printf("Merged %d new sessions from %d files into %s, dropping %d duplicates.\n", added, len(mc.Args.Files), uStats.SavePath(), dups + len(merged.Summaries) - added)
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/stats"
+	"os"
+	"strings"
+	"time"
+)
+
+type GoalCommand struct {
+	Add GoalAddCommand `command:"add" description:"Add a goal, like '15 minutes a day', '200 lines a week', or '20 wpm at 90% by june'."`
+	List GoalListCommand `command:"list" description:"List your goals and how you're doing with them."`
+	Remove GoalRemoveCommand `command:"remove" description:"Remove a goal."`
+}
+
+type GoalAddCommand struct {
+	Args struct {
+		Goal []string `positional-arg-name:"GOAL" description:"The goal." required:"1"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+type GoalListCommand struct {}
+
+type GoalRemoveCommand struct {
+	Args struct {
+		Num int `positional-arg-name:"NUM" description:"Number of the goal to remove, from 'goal list'."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func runGoalCommand(uStats *stats.UserStats, name string, gc *GoalCommand) error {
+	switch name {
+	case "add":
+		g, err := stats.ParseGoal(strings.Join(gc.Add.Args.Goal, " "), time.Now())
+		if err != nil {
+			return err
+		}
+		uStats.AddGoal(g)
+		if err = uStats.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Added goal: %s\n", g)
+	case "list":
+		if len(uStats.Goals) == 0 {
+			fmt.Println("No goals yet. Add one with 'morseudar goal add'.")
+			return nil
+		}
+		uStats.WriteProgress(os.Stdout, time.Now(), true)
+	case "remove":
+		n := gc.Remove.Args.Num
+		if err := uStats.RemoveGoal(n - 1); err != nil {
+			return err
+		}
+		if err := uStats.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Removed goal %d.\n", n)
+	}
+	return nil
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -17,11 +17,13 @@
 package audio
 
 import (
-	"fmt"
 	"github.com/ctdk/morseudar/internal/morsestrings"
 	"github.com/gopxl/beep"
 	"github.com/gopxl/beep/generators"
 	"github.com/gopxl/beep/speaker"
+	"github.com/gopxl/beep/wav"
+	"io"
+	"sync"
 	"time"
 )
 const wordAvg = 5
@@ -30,14 +32,25 @@ var sampleRate beep.SampleRate = 48000
 
 // the morse audio type should be able to toggle the audio stream on and off.
 
-// TODO: also ensure cleanup on exit
+// There's only the one speaker no matter how many MorseAudios there are, so
+// stopping it for good when shutting down is done for all of them at once.
+var (
+	stopOnce sync.Once
+	stopped = make(chan struct{})
+)
+
+// The speaker's only set up when something's actually played, so MorseAudios
+// that are only used to render files don't need a sound card.
+var (
+	speakerOnce sync.Once
+	speakerErr error
+)
 
 // MorseAudio drops the beeps.
 type MorseAudio struct {
+	Timing
 	wpm int
 	farn int
-	ditDur time.Duration
-	farnDitDur time.Duration
 	silence beep.Streamer
 	dit *beep.Buffer
 	dah *beep.Buffer
@@ -45,31 +58,30 @@ type MorseAudio struct {
 }
 
 func NewMorseAudio(freq float64, wpm int, farn int) (*MorseAudio, error) {
+	return NewMorseAudioEffects(freq, wpm, farn, DefaultEffects)
+}
+
+// NewMorseAudioEffects makes a MorseAudio whose beeps have the given effects.
+func NewMorseAudioEffects(freq float64, wpm int, farn int, fx Effects) (*MorseAudio, error) {
 	ma := new(MorseAudio)
 
 	ma.wpm = wpm
 
-	dur := calcDitDuration(wpm)
-	ma.ditDur = time.Duration(dur)
-
-	if farn != 0 {
-		fDur := calcDitDuration(farn)
-		ma.farnDitDur = time.Duration(fDur)
-	}
+	ma.Timing = NewTiming(wpm, farn)
 
 	// TODO: allow for different wave generators
 	silence := generators.Silence(-1)
 	ma.silence = silence
 
 	// dit buffer
-	ditStr, ditf, err := PreCalcSine(sampleRate, freq, ma.Dit())
+	ditStr, ditf, err := PreCalcSineEffects(sampleRate, freq, ma.Dit(), fx)
 	if err != nil {
 		return nil, err
 	}
 	ditBuf := beep.NewBuffer(ditf)
 	ditBuf.Append(ditStr)
 
-	dahStr, dahf, err := PreCalcSine(sampleRate, freq, ma.Dash())
+	dahStr, dahf, err := PreCalcSineEffects(sampleRate, freq, ma.Dash(), fx)
 	if err != nil {
 		return nil, err
 	}
@@ -80,72 +92,158 @@ func NewMorseAudio(freq float64, wpm int, farn int) (*MorseAudio, error) {
 	ma.dah = dahBuf
 
 	ma.sr = sampleRate
-	
-	speaker.Init(ma.sr, int(ma.sr / 10))
 
 	return ma, nil
 }
 
 func (ma *MorseAudio) SendMessage(ms morsestrings.MorseString) error {
-	ch := make(chan struct{})
-	morseSend := make([]beep.Streamer, 0, len(ms) * wordAvg * 2)
-
-	for _, mword := range ms {
-		lastChar := mword.Len() - 1
-		mw := make([]beep.Streamer, 0, mword.Len() * 2)
-		for i, char := range mword.Chars() {
-			for _, r := range char {
-				switch r {
-				case '.':
-					mw = append(mw, ma.dit.Streamer(0, ma.dit.Len()))
-				case '-':
-					mw = append(mw, ma.dah.Streamer(0, ma.dah.Len()))
-				default:
-					return fmt.Errorf("This should never be able to happen, but somehow '%v' got passed in as a Morse beep!", char)
-				}
-				mw = append(mw, ma.Silence(ma.Dit()))
-			}
-
-			if !mword.IsProsign() && i != lastChar {
-				mw = append(mw, ma.Silence(ma.LetterSep()))
-			}
+	morseSend, err := ma.streamers(ms)
+	if err != nil {
+		return err
+	}
+	// and done
+	return ma.play(morseSend)
+}
+
+// SendParagraphs sends a block of text as one continuous stream. The lines in
+// each paragraph run together as if they were one long line, and a longer
+// pause is inserted between paragraphs.
+func (ma *MorseAudio) SendParagraphs(paras [][]morsestrings.MorseString) error {
+	morseSend, err := ma.paragraphStreamers(paras)
+	if err != nil {
+		return err
+	}
+	return ma.play(morseSend)
+}
+
+// Render writes a block of text to w as a WAV file instead of sending it to
+// the speaker. The paragraphs are spaced out like SendParagraphs does.
+func (ma *MorseAudio) Render(w io.WriteSeeker, paras [][]morsestrings.MorseString) error {
+	morseSend, err := ma.paragraphStreamers(paras)
+	if err != nil {
+		return err
+	}
+	// WAV files only go up to 24 bits, and 16 is plenty for beeps
+	format := ma.dit.Format()
+	format.Precision = 2
+	return wav.Encode(w, beep.Seq(morseSend...), format)
+}
+
+// Samples renders a block of text to raw stereo samples, from -1 to 1, at
+// SampleRate.
+func (ma *MorseAudio) Samples(paras [][]morsestrings.MorseString) ([][2]float64, error) {
+	morseSend, err := ma.paragraphStreamers(paras)
+	if err != nil {
+		return nil, err
+	}
+
+	s := beep.Seq(morseSend...)
+	samples := make([][2]float64, 0)
+	buf := make([][2]float64, 512)
+	for {
+		n, ok := s.Stream(buf)
+		samples = append(samples, buf[:n]...)
+		if !ok {
+			break
 		}
-		mw = append(mw, ma.Silence(ma.WordSep()))
-		morseSend = append(morseSend, mw...)
 	}
+	return samples, nil
+}
+
+// SampleRate is the sample rate everything's played and rendered at.
+func SampleRate() int {
+	return int(sampleRate)
+}
 
+func (ma *MorseAudio) paragraphStreamers(paras [][]morsestrings.MorseString) ([]beep.Streamer, error) {
+	els, err := ma.ParagraphElements(paras)
+	if err != nil {
+		return nil, err
+	}
+	return ma.elementStreamers(els), nil
+}
+
+func (ma *MorseAudio) streamers(ms morsestrings.MorseString) ([]beep.Streamer, error) {
+	els, err := ma.Elements(ms)
+	if err != nil {
+		return nil, err
+	}
+	return ma.elementStreamers(els), nil
+}
+
+func (ma *MorseAudio) elementStreamers(els []Element) []beep.Streamer {
+	morseSend := make([]beep.Streamer, len(els))
+
+	for i, e := range els {
+		switch {
+		case !e.On:
+			morseSend[i] = ma.Silence(e.Dur)
+		case e.Dur == ma.Dit():
+			morseSend[i] = ma.dit.Streamer(0, ma.dit.Len())
+		default:
+			morseSend[i] = ma.dah.Streamer(0, ma.dah.Len())
+		}
+	}
+
+	return morseSend
+}
+
+// play sends the streamers to the speaker and waits until they're done, or
+// until the audio's stopped.
+func (ma *MorseAudio) play(morseSend []beep.Streamer) error {
+	select {
+	case <-stopped:
+		return nil
+	default:
+	}
+
+	speakerOnce.Do(func() {
+		speakerErr = speaker.Init(ma.sr, int(ma.sr / 10))
+	})
+	if speakerErr != nil {
+		return speakerErr
+	}
+
+	// buffered so the speaker isn't left waiting if this has stopped
+	// listening
+	ch := make(chan struct{}, 1)
 	morseSend = append(morseSend, beep.Callback(func(){
 		ch <- struct{}{}
 	}))
 
 	speaker.Play(beep.Seq(morseSend...))
-	<-ch
-
-	// and done
+	select {
+	case <-ch:
+	case <-stopped:
+		speaker.Clear()
+	}
 	return nil
 }
 
-func (ma *MorseAudio) Silence(dur time.Duration) beep.Streamer {
-	sampDur := ma.sr.N(dur)
-	return beep.Take(sampDur, ma.silence)
-}
-
-func (ma *MorseAudio) Dit() time.Duration {
-	return ma.ditDur * mDit
+// Stop cuts off whatever's being sent and keeps anything else from being sent
+// afterwards. It's for shutting down, and can be called from anywhere, as many
+// times as needed.
+func Stop() {
+	stopOnce.Do(func() {
+		close(stopped)
+		speaker.Clear()
+	})
 }
 
-func (ma *MorseAudio) Dash() time.Duration {
-	return ma.ditDur * mDash
+// resetStop undoes Stop, so the tests can keep sending afterwards whatever
+// order they run in.
+func resetStop() {
+	stopOnce = sync.Once{}
+	stopped = make(chan struct{})
 }
 
-func (ma *MorseAudio) LetterSep() time.Duration {
-	return ma.ditDur * mLetterSep
+// Close stops the audio and closes the speaker.
+func Close() {
+	Stop()
+	speaker.Close()
 }
 
-func (ma *MorseAudio) WordSep() time.Duration {
-	if ma.farnDitDur != 0 {
-		return ma.farnDitDur * mWordSep
-	}
-
-	return ma.ditDur * mWordSep
+func (ma *MorseAudio) Silence(dur time.Duration) beep.Streamer {
+	sampDur := ma.sr.N(dur)
+	return beep.Take(sampDur, ma.silence)
 }
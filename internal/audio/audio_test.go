@@ -18,7 +18,14 @@ package audio
 
 import (
 	"github.com/ctdk/morseudar/internal/morsestrings"
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/wav"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestMorseSendMessage(t *testing.T) {
@@ -46,3 +53,155 @@ func TestMorseSendMessage(t *testing.T) {
 		t.Errorf("error sending message2: %s", err.Error())
 	}
 }
+
+// The gaps between letters and words already have the silence after the last
+// dit or dah in them, so PARIS and the space after it come out to exactly 50
+// dits, which is what the WPM is worked out from. With Farnsworth timing, the
+// 43 dits of PARIS itself stay at the character speed and the 7 dit word space
+// is stretched out.
+func TestParisTiming(t *testing.T) {
+	tests := []struct {
+		wpm int
+		farn int
+		msg string
+		want func(tm Timing) time.Duration
+	}{
+		{20, 0, "paris", func(tm Timing) time.Duration { return 50 * tm.Dit() }},
+		{20, 0, "paris paris", func(tm Timing) time.Duration { return 100 * tm.Dit() }},
+		{13, 0, "paris", func(tm Timing) time.Duration { return 50 * tm.Dit() }},
+		{20, 10, "paris", func(tm Timing) time.Duration { return 43 * tm.Dit() + tm.WordSep() }},
+	}
+
+	for _, tt := range tests {
+		ma, err := NewMorseAudio(700, tt.wpm, tt.farn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ms := morsestrings.StringToMorse(tt.msg)
+		want := tt.want(ma.Timing)
+
+		els, err := ma.Elements(ms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got time.Duration
+		for _, e := range els {
+			got += e.Dur
+		}
+		if got != want {
+			t.Errorf("'%s' at %d/%d wpm should have taken %s, the elements added up to %s", tt.msg, tt.wpm, tt.farn, want, got)
+		}
+
+		// and what actually goes to the speaker should agree, give or
+		// take rounding each element to the sample rate
+		strs, err := ma.streamers(ms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		buf := make([][2]float64, 512)
+		seq := beep.Seq(strs...)
+		for {
+			c, ok := seq.Stream(buf)
+			n += c
+			if !ok {
+				break
+			}
+		}
+		sent := ma.sr.D(n)
+		if slack := time.Duration(len(els)) * ma.sr.D(1); sent < want - slack || sent > want + slack {
+			t.Errorf("'%s' at %d/%d wpm should have sent %s of audio, sent %s", tt.msg, tt.wpm, tt.farn, want, sent)
+		}
+	}
+}
+
+func TestEffects(t *testing.T) {
+	for _, fx := range []Effects{{Volume: -0.1}, {Volume: 1.5}, {Decay: -1}, {Decay: 101}} {
+		if _, err := NewMorseAudioEffects(660, 20, 0, fx); err == nil {
+			t.Errorf("effects %+v should have been rejected", fx)
+		}
+	}
+
+	// the quieter beep shouldn't ever get louder than the volume
+	sr := beep.SampleRate(44100)
+	s, _, err := PreCalcSineEffects(sr, 660, 50 * time.Millisecond, Effects{Volume: 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([][2]float64, sr.N(50 * time.Millisecond))
+	n, _ := s.Stream(samples)
+	loudest := 0.0
+	for _, smp := range samples[:n] {
+		loudest = max(loudest, math.Abs(smp[0]))
+	}
+	if loudest > 0.25 || loudest < 0.2 {
+		t.Errorf("beep at 0.25 volume peaked at %f", loudest)
+	}
+}
+
+func TestRender(t *testing.T) {
+	ma, err := NewMorseAudio(700, 20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "paris.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// PARIS is exactly 50 dits long with the space after it
+	paras := [][]morsestrings.MorseString{{morsestrings.StringToMorse("paris")}}
+	if err = ma.Render(f, paras); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	s, format, err := wav.Decode(f)
+	if err != nil {
+		t.Fatalf("rendered file didn't decode as a WAV: %s", err)
+	}
+	got := format.SampleRate.D(s.Len())
+	if want := 50 * ma.Dit(); got < want - time.Millisecond || got > want + time.Millisecond {
+		t.Errorf("'paris' at 20 wpm should have rendered as %s of audio, got %s", want, got)
+	}
+}
+
+func TestStop(t *testing.T) {
+	// Stop is for good, so the other tests need it undone afterwards.
+	t.Cleanup(resetStop)
+	ma, err := NewMorseAudio(660, 5, 0)
+	if err != nil {
+		t.Fatalf("error creating MorseAudio: %s", err.Error())
+	}
+	// this would take most of a minute at 5 wpm
+	msg := morsestrings.StringToMorse("the quick brown fox jumps over the lazy dog")
+
+	done := make(chan struct{})
+	began := time.Now()
+	go func() {
+		ma.SendMessage(msg)
+		close(done)
+	}()
+	time.Sleep(500 * time.Millisecond)
+	Stop()
+	Stop()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stopping didn't cut off the message being sent")
+	}
+	if took := time.Since(began); took > 3 * time.Second {
+		t.Errorf("sending should have stopped right away, but took %s", took)
+	}
+
+	// nothing more gets sent once it's stopped
+	began = time.Now()
+	ma.SendMessage(msg)
+	if took := time.Since(began); took > time.Second {
+		t.Errorf("sending after stopping should return right away, but took %s", took)
+	}
+}
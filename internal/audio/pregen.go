@@ -35,7 +35,51 @@ type preCalcSine struct {
 const decayLenPercentage = 5
 const decayFactor float64 = 0.90
 
+// Effects change how the beeps sound. Zero values are taken to mean the
+// defaults.
+type Effects struct {
+	// Volume of the beeps, from 0 to 1.
+	Volume float64
+	// Decay is how much of the end of each beep, in percent, gets faded
+	// out to keep it from clicking.
+	Decay int
+}
+
+// DefaultEffects are how the beeps sound unless asked otherwise.
+var DefaultEffects = Effects{Volume: 1, Decay: decayLenPercentage}
+
+// Validate checks that the effects are in range.
+func (fx Effects) Validate() error {
+	if fx.Volume < 0 || fx.Volume > 1 {
+		return errors.New("volume must be between 0 and 1")
+	}
+	if fx.Decay < 0 || fx.Decay > 100 {
+		return errors.New("decay must be between 0 and 100 percent")
+	}
+	return nil
+}
+
+func (fx Effects) withDefaults() Effects {
+	if fx.Volume == 0 {
+		fx.Volume = DefaultEffects.Volume
+	}
+	if fx.Decay == 0 {
+		fx.Decay = DefaultEffects.Decay
+	}
+	return fx
+}
+
 func PreCalcSine(sr beep.SampleRate, freq float64, dur time.Duration) (beep.StreamSeekCloser, beep.Format, error) {
+	return PreCalcSineEffects(sr, freq, dur, DefaultEffects)
+}
+
+// PreCalcSineEffects is PreCalcSine, but with the effects applied.
+func PreCalcSineEffects(sr beep.SampleRate, freq float64, dur time.Duration, fx Effects) (beep.StreamSeekCloser, beep.Format, error) {
+	if err := fx.Validate(); err != nil {
+		return nil, beep.Format{}, err
+	}
+	fx = fx.withDefaults()
+
 	dt := freq / float64(sr)
 	if dt > 1.0/2.0 {
 		return nil, beep.Format{}, errors.New("sample rate must be at least two times greater than the frequency")
@@ -47,12 +91,12 @@ func PreCalcSine(sr beep.SampleRate, freq float64, dur time.Duration) (beep.Stre
 
 	var t float64 = 0
 
-	decayLen := sampleLen * decayLenPercentage / 100
+	decayLen := sampleLen * fx.Decay / 100
 	decayStep := 0
 
 	for i := 0; i < sampleLen; i++ {
 		var n [2]float64
-		v := math.Sin(t * 2.0 * math.Pi)
+		v := math.Sin(t * 2.0 * math.Pi) * fx.Volume
 
 		// damp down the end a bit
 		if i > sampleLen - decayLen {
@@ -90,7 +134,7 @@ func (pc *preCalcSine) Stream(samples [][2]float64) (n int, ok bool) {
 
 	for i := range samples {
 		if pc.pos >= sampleLen {
-			return 0, false
+			return n, n > 0
 		}
 
 		samples[i] = pc.samples[pc.pos]
@@ -18,6 +18,7 @@ package audio
 
 import (
 	"fmt"
+	"github.com/ctdk/morseudar/internal/morsestrings"
 	"time"
 )
 
@@ -28,6 +29,7 @@ const (
 	mDash = 3
 	mLetterSep = 3
 	mWordSep = 7
+	mParagraphSep = 21 // not standard, but three word spaces feels right
 	mParis = 50 // PARIS using 50 dits for wpm calculations.
 )
 
@@ -41,3 +43,111 @@ func calcDitDuration(wpm int) DitDuration {
 func (d DitDuration) String() string {
 	return fmt.Sprintf("%.3f", float64(d) / float64(time.Second))
 }
+
+// Timing is how long each part of Morse code lasts at a particular speed, with
+// or without Farnsworth spacing.
+type Timing struct {
+	ditDur time.Duration
+	farnDitDur time.Duration
+}
+
+// Element is one beep, or one silence, in a stretch of Morse code.
+type Element struct {
+	On bool
+	Dur time.Duration
+}
+
+// NewTiming works out the timing for the given WPM and Farnsworth speed. A
+// farn of 0 means no Farnsworth spacing.
+func NewTiming(wpm int, farn int) Timing {
+	var t Timing
+	t.ditDur = time.Duration(calcDitDuration(wpm))
+	if farn != 0 {
+		t.farnDitDur = time.Duration(calcDitDuration(farn))
+	}
+	return t
+}
+
+func (t Timing) Dit() time.Duration {
+	return t.ditDur * mDit
+}
+
+func (t Timing) Dash() time.Duration {
+	return t.ditDur * mDash
+}
+
+func (t Timing) LetterSep() time.Duration {
+	return t.ditDur * mLetterSep
+}
+
+func (t Timing) WordSep() time.Duration {
+	if t.farnDitDur != 0 {
+		return t.farnDitDur * mWordSep
+	}
+
+	return t.ditDur * mWordSep
+}
+
+// ParagraphSep is the pause between paragraphs when sending an entire block of
+// text. Like the word separation, it's stretched out by Farnsworth timing.
+func (t Timing) ParagraphSep() time.Duration {
+	if t.farnDitDur != 0 {
+		return t.farnDitDur * mParagraphSep
+	}
+
+	return t.ditDur * mParagraphSep
+}
+
+// Elements lays out the beeps and silences for a line of Morse code, ending
+// with the space after the last word. Everything that sends or renders Morse
+// goes through this, so they all agree on the timing.
+func (t Timing) Elements(ms morsestrings.MorseString) ([]Element, error) {
+	els := make([]Element, 0, len(ms) * wordAvg * 4)
+
+	for _, mword := range ms {
+		lastChar := mword.Len() - 1
+		for i, char := range mword.Chars() {
+			for _, r := range char {
+				switch r {
+				case '.':
+					els = append(els, Element{On: true, Dur: t.Dit()})
+				case '-':
+					els = append(els, Element{On: true, Dur: t.Dash()})
+				default:
+					return nil, fmt.Errorf("This should never be able to happen, but somehow '%v' got passed in as a Morse beep!", char)
+				}
+				els = append(els, Element{Dur: t.Dit()})
+			}
+
+			// the gaps include the one already after the last
+			// dit or dah
+			if !mword.IsProsign() && i != lastChar {
+				els = append(els, Element{Dur: t.LetterSep() - t.Dit()})
+			}
+		}
+		els = append(els, Element{Dur: t.WordSep() - t.Dit()})
+	}
+
+	return els, nil
+}
+
+// ParagraphElements lays out a whole block of paragraphs, with the longer
+// pause between them.
+func (t Timing) ParagraphElements(paras [][]morsestrings.MorseString) ([]Element, error) {
+	els := make([]Element, 0)
+
+	for i, para := range paras {
+		if i != 0 {
+			els = append(els, Element{Dur: t.ParagraphSep()})
+		}
+		for _, ms := range para {
+			e, err := t.Elements(ms)
+			if err != nil {
+				return nil, err
+			}
+			els = append(els, e...)
+		}
+	}
+
+	return els, nil
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package config reads morseudar's config file, which holds the default
+// settings to use when they aren't given on the command line. Settings can be
+// overridden for each mode, so qcodes can be practiced slower than topwords,
+// say.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"os"
+	"sort"
+	"strings"
+)
+
+// FileName is the name of the config file in the data directory.
+const FileName = "config.toml"
+
+// Config is the whole config file. Zero values mean "not set".
+type Config struct {
+	Mode string `toml:"mode"`
+	Settings
+	Modes map[string]Settings `toml:"modes"`
+}
+
+// Settings are the settings that can be given at the top level of the config
+// file and for each mode.
+type Settings struct {
+	Wpm int `toml:"wpm"`
+	Farnsworth int `toml:"farnsworth"`
+	Frequency int `toml:"frequency"`
+	TopWordNum int `toml:"top_word_num"`
+	Compare Compare `toml:"compare"`
+	Audio Audio `toml:"audio"`
+}
+
+// Compare holds the costs of each kind of mistake when scoring answers.
+type Compare struct {
+	ReplaceCost int `toml:"replace_cost"`
+	InsertCost int `toml:"insert_cost"`
+	DeleteCost int `toml:"delete_cost"`
+}
+
+// Audio holds the effects applied to the beeps.
+type Audio struct {
+	Volume float64 `toml:"volume"`
+	Decay int `toml:"decay"`
+}
+
+// Load reads the config file at path. A missing config file isn't an error,
+// it just means everything's the default, but unknown settings are, since
+// they're most likely typos.
+func Load(path string) (*Config, error) {
+	c := new(Config)
+	md, err := toml.DecodeFile(path, c)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return new(Config), nil
+		}
+		return nil, fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+	if und := md.Undecoded(); len(und) > 0 {
+		keys := make([]string, len(und))
+		for i, k := range und {
+			keys[i] = k.String()
+		}
+		return nil, fmt.Errorf("unknown settings in config file %s: %s", path, strings.Join(keys, ", "))
+	}
+
+	// mode names aren't case sensitive anywhere else
+	c.Mode = strings.ToLower(c.Mode)
+	if len(c.Modes) > 0 {
+		modes := make(map[string]Settings, len(c.Modes))
+		for k, v := range c.Modes {
+			modes[strings.ToLower(k)] = v
+		}
+		c.Modes = modes
+	}
+
+	if err = c.Validate(); err != nil {
+		return nil, fmt.Errorf("error in config file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate checks that none of the settings are out of range. It can't check
+// the mode names; that's up to the caller.
+func (c *Config) Validate() error {
+	if err := c.Settings.validate(); err != nil {
+		return err
+	}
+	for _, name := range c.ModeNames() {
+		if err := c.Modes[name].validate(); err != nil {
+			return fmt.Errorf("[modes.%s]: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (s Settings) validate() error {
+	if s.Wpm < 0 || s.Farnsworth < 0 || s.Frequency < 0 || s.TopWordNum < 0 {
+		return errors.New("wpm, farnsworth, frequency, and top_word_num can't be negative")
+	}
+	if s.Compare.ReplaceCost < 0 || s.Compare.InsertCost < 0 || s.Compare.DeleteCost < 0 {
+		return errors.New("comparison costs can't be negative")
+	}
+	if s.Audio.Volume < 0 || s.Audio.Volume > 1 {
+		return errors.New("volume must be between 0 and 1")
+	}
+	if s.Audio.Decay < 0 || s.Audio.Decay > 100 {
+		return errors.New("decay must be between 0 and 100 percent")
+	}
+	return nil
+}
+
+// ModeNames returns the names of the modes with their own settings, sorted.
+func (c *Config) ModeNames() []string {
+	names := make([]string, 0, len(c.Modes))
+	for k := range c.Modes {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// For returns the settings to use in the given mode: the mode's own settings,
+// with anything they leave unset filled in from the top level.
+func (c *Config) For(mode string) Settings {
+	s := c.Settings
+	ms, ok := c.Modes[strings.ToLower(mode)]
+	if !ok {
+		return s
+	}
+	if ms.Wpm != 0 {
+		s.Wpm = ms.Wpm
+	}
+	if ms.Farnsworth != 0 {
+		s.Farnsworth = ms.Farnsworth
+	}
+	if ms.Frequency != 0 {
+		s.Frequency = ms.Frequency
+	}
+	if ms.TopWordNum != 0 {
+		s.TopWordNum = ms.TopWordNum
+	}
+	if ms.Compare.ReplaceCost != 0 {
+		s.Compare.ReplaceCost = ms.Compare.ReplaceCost
+	}
+	if ms.Compare.InsertCost != 0 {
+		s.Compare.InsertCost = ms.Compare.InsertCost
+	}
+	if ms.Compare.DeleteCost != 0 {
+		s.Compare.DeleteCost = ms.Compare.DeleteCost
+	}
+	if ms.Audio.Volume != 0 {
+		s.Audio.Volume = ms.Audio.Volume
+	}
+	if ms.Audio.Decay != 0 {
+		s.Audio.Decay = ms.Audio.Decay
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+mode = "TopWords"
+wpm = 20
+farnsworth = 12
+frequency = 650
+
+[compare]
+delete_cost = 2
+
+[audio]
+volume = 0.5
+
+[modes.qcodes]
+wpm = 15
+
+[modes.Chars]
+farnsworth = 5
+audio.decay = 10
+`
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoad(t *testing.T) {
+	c, err := Load(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Mode != "topwords" {
+		t.Errorf("mode should have been lowercased to 'topwords', got '%s'", c.Mode)
+	}
+	if c.Wpm != 20 || c.Farnsworth != 12 || c.Frequency != 650 {
+		t.Errorf("top level settings didn't load: %+v", c.Settings)
+	}
+	if c.Compare.DeleteCost != 2 || c.Audio.Volume != 0.5 {
+		t.Errorf("compare and audio settings didn't load: %+v", c.Settings)
+	}
+	if names := strings.Join(c.ModeNames(), ","); names != "chars,qcodes" {
+		t.Errorf("expected modes 'chars,qcodes', got '%s'", names)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("a missing config file shouldn't be an error: %s", err)
+	}
+	if c.Mode != "" || c.Settings != (Settings{}) || len(c.Modes) != 0 {
+		t.Errorf("a missing config file should give an empty config, got %+v", c)
+	}
+}
+
+func TestLoadBad(t *testing.T) {
+	bad := map[string]string{
+		"unknown key": "wmp = 20\n",
+		"negative": "wpm = -5\n",
+		"loud": "[audio]\nvolume = 2.0\n",
+		"bad mode setting": "[modes.qcodes]\nfrequency = -1\n",
+		"not toml": "wpm = \n",
+	}
+	for desc, body := range bad {
+		if _, err := Load(writeConfig(t, body)); err == nil {
+			t.Errorf("config with %s should have failed to load", desc)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+	c, err := Load(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := c.For("qcodes")
+	if q.Wpm != 15 || q.Farnsworth != 12 || q.Frequency != 650 {
+		t.Errorf("qcodes should override wpm and keep the rest, got %+v", q)
+	}
+	ch := c.For("CHARS")
+	if ch.Wpm != 20 || ch.Farnsworth != 5 || ch.Audio.Decay != 10 || ch.Audio.Volume != 0.5 {
+		t.Errorf("chars should override farnsworth and decay and keep the rest, got %+v", ch)
+	}
+	if tw := c.For("topwords"); tw != c.Settings {
+		t.Errorf("a mode without its own settings should get the top level ones, got %+v", tw)
+	}
+}
@@ -17,8 +17,10 @@
 package compare
 
 import (
+	"errors"
 	"github.com/adrg/strutil"
 	"github.com/adrg/strutil/metrics"
+	"strings"
 	"time"
 )
 
@@ -29,11 +31,19 @@ const (
 )
 
 type Answer struct {
-	Original string
-	Response string
-	Percentage float64
-	Took time.Duration
-	Tries int
+	Date time.Time `json:"date"`
+	Original string `json:"original"`
+	Response string `json:"response"`
+	Percentage float64 `json:"percentage"`
+	Took time.Duration `json:"took_ns"`
+	Tries int `json:"tries"`
+	// The settings the line was last sent with, since they can be changed
+	// in the middle of a session.
+	Wpm int `json:"wpm"`
+	Farnsworth int `json:"farnsworth"`
+	Frequency float64 `json:"frequency"`
+	Hinted bool `json:"hinted"`
+	Skipped bool `json:"skipped"`
 }
 
 type AnswerBatch []Answer
@@ -44,6 +54,28 @@ type Comparator struct {
 }
 
 func New() *Comparator {
+	c, _ := NewWithCosts(Costs{})
+	return c
+}
+
+// Costs are how much each kind of edit counts against an answer when scoring
+// it. Zero values get the usual cost of 1.
+type Costs struct {
+	Replace int
+	Insert int
+	Delete int
+}
+
+// DefaultCosts are the edit costs used by New.
+var DefaultCosts = Costs{Replace: levReplaceCost, Insert: levInsertCost, Delete: levDeleteCost}
+
+// NewWithCosts makes a Comparator that scores answers with different edit
+// costs, say to be harder on dropped characters than wrong ones.
+func NewWithCosts(costs Costs) (*Comparator, error) {
+	if costs.Replace < 0 || costs.Insert < 0 || costs.Delete < 0 {
+		return nil, errors.New("edit costs can't be negative")
+	}
+
 	// try Levenshein
 	lev := metrics.NewLevenshtein()
 	lev.CaseSensitive = false
@@ -52,8 +84,17 @@ func New() *Comparator {
 	lev.ReplaceCost = levReplaceCost
 	lev.InsertCost = levInsertCost
 	lev.DeleteCost = levDeleteCost
+	if costs.Replace != 0 {
+		lev.ReplaceCost = costs.Replace
+	}
+	if costs.Insert != 0 {
+		lev.InsertCost = costs.Insert
+	}
+	if costs.Delete != 0 {
+		lev.DeleteCost = costs.Delete
+	}
 
-	return &Comparator{lev: lev}
+	return &Comparator{lev: lev}, nil
 }
 
 func CompareStrings(orig string, resp string) float64 {
@@ -73,7 +114,8 @@ func CompareStrings(orig string, resp string) float64 {
 func (c *Comparator) Compare(orig string, resp string, start time.Time, tries int) Answer {
 	sim := strutil.Similarity(orig, resp, c.lev)
 	took := time.Since(start)
-	ans := Answer{ Original: orig,
+	ans := Answer{ Date: time.Now(),
+		Original: orig,
 		Response: resp,
 		Percentage: sim,
 		Took: took,
@@ -83,6 +125,110 @@ func (c *Comparator) Compare(orig string, resp string, start time.Time, tries in
 	return ans
 }
 
+// CompareBlock scores a transcription of an entire block of text, the way a
+// copy exam would. The words in the response are aligned with the words in the
+// original, and then split back up into the sections (usually lines) of the
+// original so each section can be scored on its own. This way a dropped or
+// extra word only costs points in the section it happened in, rather than
+// throwing off the rest of the block.
+func (c *Comparator) CompareBlock(orig []string, resp string, start time.Time, tries int) AnswerBatch {
+	now := time.Now()
+	took := now.Sub(start)
+
+	var origWords []string
+	var origSecs []int
+	for i, sec := range orig {
+		for _, w := range strings.Fields(sec) {
+			origWords = append(origWords, w)
+			origSecs = append(origSecs, i)
+		}
+	}
+	respWords := strings.Fields(resp)
+	respSecs := c.alignWords(origWords, origSecs, respWords)
+
+	secResp := make([][]string, len(orig))
+	for j, w := range respWords {
+		secResp[respSecs[j]] = append(secResp[respSecs[j]], w)
+	}
+
+	ab := make(AnswerBatch, len(orig))
+	for i, sec := range orig {
+		r := strings.Join(secResp[i], " ")
+
+		// split the time taken up by how long each section is
+		var secTook time.Duration
+		if len(origWords) > 0 {
+			secTook = took * time.Duration(len(strings.Fields(sec))) / time.Duration(len(origWords))
+		}
+
+		ab[i] = Answer{ Date: now,
+			Original: sec,
+			Response: r,
+			Percentage: strutil.Similarity(sec, r, c.lev),
+			Took: secTook,
+			Tries: tries,
+		}
+	}
+
+	return ab
+}
+
+// alignWords lines up the response words with the original words with a
+// word-level edit distance, and returns which section of the original each
+// response word belongs to. Substituting one word for another costs less the
+// more alike they are, so a misspelled word still lines up with the word it
+// was supposed to be.
+func (c *Comparator) alignWords(origWords []string, origSecs []int, respWords []string) []int {
+	n := len(origWords)
+	m := len(respWords)
+	respSecs := make([]int, m)
+	if n == 0 {
+		return respSecs
+	}
+
+	cost := make([][]float64, n + 1)
+	for i := range cost {
+		cost[i] = make([]float64, m + 1)
+		cost[i][0] = float64(i)
+	}
+	for j := 0; j <= m; j++ {
+		cost[0][j] = float64(j)
+	}
+
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			sub := cost[i-1][j-1] + 1 - strutil.Similarity(origWords[i-1], respWords[j-1], c.lev)
+			del := cost[i-1][j] + 1
+			ins := cost[i][j-1] + 1
+			cost[i][j] = min(sub, del, ins)
+		}
+	}
+
+	// walk back through the table, assigning each response word to the
+	// section of the original word it lined up with. Extra words go with
+	// the original word just before them.
+	i, j := n, m
+	for j > 0 {
+		switch {
+		case i > 0 && cost[i][j] == cost[i-1][j-1] + 1 - strutil.Similarity(origWords[i-1], respWords[j-1], c.lev):
+			respSecs[j-1] = origSecs[i-1]
+			i--
+			j--
+		case i > 0 && cost[i][j] == cost[i-1][j] + 1:
+			i--
+		default:
+			if i > 0 {
+				respSecs[j-1] = origSecs[i-1]
+			} else {
+				respSecs[j-1] = origSecs[0]
+			}
+			j--
+		}
+	}
+
+	return respSecs
+}
+
 func (ab AnswerBatch) Averages() (float64, time.Duration, float64) {
 	n := len(ab)
 	if n == 0 {
@@ -104,3 +250,48 @@ func (ab AnswerBatch) Averages() (float64, time.Duration, float64) {
 
 	return avgPerc, avgDur, avgTries
 }
+
+// AlignChars lines up the response with the original using the same sort of
+// edit distance the scoring uses, and returns whether each character of the
+// original was copied correctly.
+func AlignChars(orig []rune, resp []rune) []bool {
+	n, m := len(orig), len(resp)
+	dist := make([][]int, n + 1)
+	for i := range dist {
+		dist[i] = make([]int, m + 1)
+		dist[i][0] = i
+	}
+	for j := 0; j <= m; j++ {
+		dist[0][j] = j
+	}
+
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			sub := dist[i-1][j-1]
+			if orig[i-1] != resp[j-1] {
+				sub++
+			}
+			dist[i][j] = min(sub, dist[i-1][j] + 1, dist[i][j-1] + 1)
+		}
+	}
+
+	copied := make([]bool, n)
+	i, j := n, m
+	for i > 0 {
+		switch {
+		case j > 0 && orig[i-1] == resp[j-1] && dist[i][j] == dist[i-1][j-1]:
+			copied[i-1] = true
+			i--
+			j--
+		case j > 0 && dist[i][j] == dist[i-1][j-1] + 1:
+			i--
+			j--
+		case dist[i][j] == dist[i-1][j] + 1:
+			i--
+		default:
+			j--
+		}
+	}
+
+	return copied
+}
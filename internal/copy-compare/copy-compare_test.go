@@ -19,6 +19,7 @@ package compare
 import (
 	"math"
 	"testing"
+	"time"
 )
 
 var ep float64 = 0.000001
@@ -73,9 +74,90 @@ func TestCopyCompareAlmost(t *testing.T) {
 	}
 }
 
+func TestCompareBlock(t *testing.T) {
+	c := New()
+	orig := []string{"the quick brown", "fox jumps over", "the lazy dog"}
+	resp := "the quick brwn fox over\nthe lazy dog dog"
+	ab := c.CompareBlock(orig, resp, time.Now(), 1)
+
+	if len(ab) != len(orig) {
+		t.Fatalf("expected %d sections, got %d", len(orig), len(ab))
+	}
+	expResp := []string{"the quick brwn", "fox over", "the lazy dog dog"}
+	for i, ans := range ab {
+		if ans.Original != orig[i] {
+			t.Errorf("section %d original should have been '%s', got '%s'", i, orig[i], ans.Original)
+		}
+		if ans.Response != expResp[i] {
+			t.Errorf("section %d response should have been '%s', got '%s'", i, expResp[i], ans.Response)
+		}
+		if !floatEq(ans.Percentage, CompareStrings(orig[i], expResp[i])) {
+			t.Errorf("section %d score was %f, expected %f", i, ans.Percentage, CompareStrings(orig[i], expResp[i]))
+		}
+	}
+}
+
+func TestCompareBlockEmpty(t *testing.T) {
+	c := New()
+	orig := []string{"foo bar", "baz"}
+	ab := c.CompareBlock(orig, "", time.Now(), 1)
+	for i, ans := range ab {
+		if ans.Response != "" || ans.Percentage != 0 {
+			t.Errorf("section %d of an empty response should have been empty and scored 0, got '%s' and %f", i, ans.Response, ans.Percentage)
+		}
+	}
+}
+
 func floatEq(a float64, b float64) bool {
 	if math.Abs(a - b) > ep {
 		return false
 	}
 	return true
 }
+
+func TestAlignChars(t *testing.T) {
+	tests := []struct {
+		orig string
+		resp string
+		exp string
+	}{
+		{"hello", "hello", "yyyyy"},
+		{"hello", "helo", "yynyy"},
+		{"hello", "hxllo", "ynyyy"},
+		{"qrm", "", "nnn"},
+		{"ab", "xaby", "yy"},
+	}
+	for _, v := range tests {
+		copied := AlignChars([]rune(v.orig), []rune(v.resp))
+		got := make([]byte, len(copied))
+		for i, c := range copied {
+			got[i] = 'n'
+			if c {
+				got[i] = 'y'
+			}
+		}
+		if string(got) != v.exp {
+			t.Errorf("aligning '%s' and '%s' should have given %s, got %s", v.orig, v.resp, v.exp, got)
+		}
+	}
+}
+
+func TestNewWithCosts(t *testing.T) {
+	if _, err := NewWithCosts(Costs{Delete: -1}); err == nil {
+		t.Error("negative costs should have been rejected")
+	}
+
+	// dropping a character should hurt more when deletions cost more
+	usual := New().Compare("morse", "mose", time.Now(), 1)
+	c, err := NewWithCosts(Costs{Delete: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	costly := c.Compare("morse", "mose", time.Now(), 1)
+	if costly.Percentage >= usual.Percentage {
+		t.Errorf("a dropped character should have scored worse with costly deletions: %f vs. %f", costly.Percentage, usual.Percentage)
+	}
+	if wrong := c.Compare("morse", "morsa", time.Now(), 1); wrong.Percentage != usual.Percentage {
+		t.Errorf("a wrong character shouldn't have been affected by the deletion cost, scored %f", wrong.Percentage)
+	}
+}
@@ -0,0 +1,526 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package decoder decodes Morse code from audio, like a WAV file of
+// morseudar's own rendering or a recording off the air. It finds the tone
+// with Goertzel filters, works out the speed from how long the beeps and gaps
+// are, and turns them back into text.
+package decoder
+
+import (
+	"errors"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"github.com/gopxl/beep/wav"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// what frequencies to look for the tone at, in Hz. Recordings with a low
+// sample rate are only searched up to a bit under their Nyquist frequency.
+const (
+	minFrequency = 200
+	maxFrequency = 4000
+	frequencyStep = 50
+)
+
+// WAV files are decimated to at least this rate, more or less, as they're read
+// in, so long recordings don't take up as much memory or time to decode.
+// Anything over it is wasted on tones under maxFrequency anyway.
+const decimatedRate = 10000
+
+// The envelope of the tone is measured over windowMs, every hopMs. The window
+// needs to be long enough to pick the tone out of the noise, but short enough
+// to not blur the gaps between dits together at high speeds.
+const (
+	windowMs = 10
+	hopMs = 1
+)
+
+// ErrNoMorse is returned when there isn't a tone that goes on and off like
+// Morse code.
+var ErrNoMorse = errors.New("no Morse code found")
+
+// Settings are optional hints for decoding. Zero values mean they're worked
+// out from the audio.
+type Settings struct {
+	// Frequency is the pitch of the tone to decode, in Hz, for when
+	// there's more than one signal and the loudest isn't the one wanted.
+	Frequency float64
+}
+
+// Result is the decoded text, and what the Morse code was sent with.
+type Result struct {
+	// Text has a space between words and a newline between paragraphs, or
+	// for long pauses. Characters that didn't decode are '*'.
+	Text string
+	// WPM is the estimated character speed.
+	WPM int
+	// Farnsworth is the estimated speed of the spacing, if it's slower
+	// than the characters, or 0.
+	Farnsworth int
+	// Frequency is the pitch of the tone, in Hz.
+	Frequency float64
+}
+
+// DecodeWAV decodes a WAV file. Stereo files are mixed down to mono, and the
+// sample rate is brought down to around decimatedRate by averaging the samples
+// together as they're read.
+func DecodeWAV(r io.Reader, s Settings) (*Result, error) {
+	streamer, format, err := wav.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	defer streamer.Close()
+
+	// keep enough of the rate for the tone asked for, if it's a high one
+	factor := max(int(format.SampleRate) / max(decimatedRate, int(s.Frequency * 2.5)), 1)
+	samples := make([]float64, 0, max(streamer.Len() / factor, 0))
+	buf := make([][2]float64, 4096)
+	var sum float64
+	var n int
+	for {
+		c, ok := streamer.Stream(buf)
+		for _, st := range buf[:c] {
+			sum += (st[0] + st[1]) / 2
+			if n++; n == factor {
+				samples = append(samples, sum / float64(factor))
+				sum, n = 0, 0
+			}
+		}
+		if !ok {
+			break
+		}
+	}
+	if err = streamer.Err(); err != nil {
+		return nil, err
+	}
+
+	return DecodeSamples(samples, int(format.SampleRate) / factor, s)
+}
+
+// DecodeSamples decodes mono PCM samples, from -1 to 1, at the given sample
+// rate.
+func DecodeSamples(samples []float64, sampleRate int, s Settings) (*Result, error) {
+	if sampleRate <= 0 {
+		return nil, errors.New("the sample rate has to be positive")
+	}
+	window := sampleRate * windowMs / 1000
+	hop := sampleRate * hopMs / 1000
+	if window == 0 || hop == 0 || len(samples) < window {
+		return nil, ErrNoMorse
+	}
+
+	freq := s.Frequency
+	if freq == 0 {
+		freq = findTone(samples, sampleRate, window)
+	}
+	runs, err := toneRuns(envelope(samples, sampleRate, freq, window, hop))
+	if err != nil {
+		return nil, err
+	}
+
+	// Noise can chop beeps up, or add little ones, so once there's an idea
+	// of how long a dit is, anything a lot shorter is smoothed over and
+	// the dit's worked out again.
+	runs = smooth(runs, 3)
+	dit := estimateDit(runs)
+	runs = smooth(runs, dit / 2.5)
+	if len(runs) == 0 {
+		return nil, ErrNoMorse
+	}
+	dit = refineDit(runs, estimateDit(runs))
+
+	sp := classifyGaps(runs, dit)
+	res := &Result{Frequency: freq, Text: sp.decode(runs, dit)}
+	ditSecs := dit * hopMs / 1000
+	res.WPM = int(math.Round(1.2 / ditSecs))
+	if sp.unit > dit * 1.1 {
+		if farn := int(math.Round(1.2 / (sp.unit * hopMs / 1000))); farn < res.WPM {
+			res.Farnsworth = farn
+		}
+	}
+	return res, nil
+}
+
+// goertzel returns the amplitude of the frequency the coefficient is for in
+// the samples.
+func goertzel(samples []float64, coeff float64) float64 {
+	var s1, s2 float64
+	for _, x := range samples {
+		s1, s2 = x + coeff * s1 - s2, s1
+	}
+	power := s1 * s1 + s2 * s2 - coeff * s1 * s2
+	return math.Sqrt(max(power, 0)) * 2 / float64(len(samples))
+}
+
+func coefficient(freq float64, sampleRate int) float64 {
+	return 2 * math.Cos(2 * math.Pi * freq / float64(sampleRate))
+}
+
+// findTone finds the frequency with the most going on, first roughly, then
+// more closely around the best of the rough guesses.
+func findTone(samples []float64, sampleRate int, window int) float64 {
+	power := func(freq float64) float64 {
+		coeff := coefficient(freq, sampleRate)
+		var total float64
+		for i := 0; i + window <= len(samples); i += window {
+			a := goertzel(samples[i:i + window], coeff)
+			total += a * a
+		}
+		return total
+	}
+	best := func(from, to, step float64) float64 {
+		bestFreq, bestPower := from, -1.0
+		for f := from; f <= to; f += step {
+			if p := power(f); p > bestPower {
+				bestFreq, bestPower = f, p
+			}
+		}
+		return bestFreq
+	}
+
+	rough := best(minFrequency, min(maxFrequency, float64(sampleRate) * 0.45), frequencyStep)
+	return best(rough - frequencyStep, rough + frequencyStep, 5)
+}
+
+// envelope is the amplitude of the tone through the samples, every hop.
+func envelope(samples []float64, sampleRate int, freq float64, window int, hop int) []float64 {
+	coeff := coefficient(freq, sampleRate)
+	env := make([]float64, 0, len(samples) / hop)
+	for i := 0; i + window <= len(samples); i += hop {
+		env = append(env, goertzel(samples[i:i + window], coeff))
+	}
+	return env
+}
+
+// run is a stretch of tone or silence, n hops long.
+type run struct {
+	on bool
+	n float64
+}
+
+// toneRuns turns the envelope into runs of tone and silence, with the leading
+// and trailing silence trimmed off. The threshold is between the loud and
+// quiet levels, with some hysteresis so noise right at the threshold doesn't
+// flicker back and forth.
+func toneRuns(env []float64) ([]run, error) {
+	sorted := slices.Clone(env)
+	slices.Sort(sorted)
+	k, quiet, loud := split(sorted)
+	if k == 0 || k == len(sorted) || loud < quiet * 3 || loud == 0 {
+		return nil, ErrNoMorse
+	}
+	onAt := quiet + (loud - quiet) * 0.6
+	offAt := quiet + (loud - quiet) * 0.4
+
+	var runs []run
+	on := false
+	for _, a := range env {
+		switch {
+		case !on && a > onAt:
+			on = true
+		case on && a < offAt:
+			on = false
+		}
+		if len(runs) > 0 && runs[len(runs) - 1].on == on {
+			runs[len(runs) - 1].n++
+		} else {
+			runs = append(runs, run{on: on, n: 1})
+		}
+	}
+	return trim(runs), nil
+}
+
+func trim(runs []run) []run {
+	for len(runs) > 0 && !runs[0].on {
+		runs = runs[1:]
+	}
+	for len(runs) > 0 && !runs[len(runs) - 1].on {
+		runs = runs[:len(runs) - 1]
+	}
+	return runs
+}
+
+// smooth gets rid of runs shorter than min, merging them and the runs on
+// either side into one.
+func smooth(runs []run, min float64) []run {
+	out := make([]run, 0, len(runs))
+	for _, r := range runs {
+		switch {
+		case len(out) > 0 && out[len(out) - 1].on == r.on:
+			out[len(out) - 1].n += r.n
+		case r.n < min && len(out) > 0:
+			// swallowed by the run before, and the one after joins
+			// it too
+			out[len(out) - 1].n += r.n
+		default:
+			out = append(out, r)
+		}
+	}
+	// a short beep right at the start or end
+	for len(out) > 0 && out[0].on && out[0].n < min {
+		out = trim(out[1:])
+	}
+	for len(out) > 0 && out[len(out) - 1].on && out[len(out) - 1].n < min {
+		out = trim(out[:len(out) - 1])
+	}
+	return out
+}
+
+func lengths(runs []run, on bool) []float64 {
+	var ls []float64
+	for _, r := range runs {
+		if r.on == on {
+			ls = append(ls, r.n)
+		}
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+// estimateDit works out how long a dit is from how the lengths of the beeps
+// are spread out. Dahs are three dits long, so if there are two clear groups
+// of beeps, those are the dits and dahs. If they're all about the same, they
+// could be either, and the gaps between them break the tie: the shortest gaps
+// are a dit long, inside characters, so beeps much longer than them are dahs.
+func estimateDit(runs []run) float64 {
+	marks := lengths(runs, true)
+	if len(marks) == 0 {
+		return 1
+	}
+	k, short, long := logSplit(marks)
+	if k > 0 && k < len(marks) && long > short * 2 {
+		var total float64
+		for i, m := range marks {
+			if i < k {
+				total += m
+			} else {
+				total += m / 3
+			}
+		}
+		return total / float64(len(marks))
+	}
+
+	m := mean(marks)
+	gaps := lengths(runs, false)
+	if len(gaps) > 0 && m >= gaps[len(gaps) / 10] * 2 {
+		return m / 3
+	}
+	return m
+}
+
+// refineDit corrects the length of a dit for beeps coming out a little longer
+// or shorter than they should, which happens when they fade in and out or the
+// tone isn't much louder than the noise. Whatever a beep gains, the gap after
+// it loses, so the difference between the dits and the gaps inside characters
+// (which should be the same length) is how far off the beeps are.
+func refineDit(runs []run, dit float64) float64 {
+	var dits, gaps []float64
+	for _, r := range runs {
+		switch {
+		case r.n >= dit * 2:
+		case r.on:
+			dits = append(dits, r.n)
+		default:
+			gaps = append(gaps, r.n)
+		}
+	}
+	if len(dits) == 0 || len(gaps) == 0 {
+		return dit
+	}
+	off := (mean(gaps) - mean(dits)) / 2
+
+	var total float64
+	var count int
+	for _, r := range runs {
+		if !r.on {
+			continue
+		}
+		if r.n < dit * 2 {
+			total += r.n + off
+		} else {
+			total += (r.n + off) / 3
+		}
+		count++
+	}
+	return total / float64(count)
+}
+
+// spacing is how the gaps between beeps are told apart, in hops.
+type spacing struct {
+	// gaps longer than these are between words, and paragraphs
+	word float64
+	paragraph float64
+	// how long a dit would be going by the gaps, which is longer than a
+	// real dit with Farnsworth timing
+	unit float64
+}
+
+// classifyGaps works out which gaps are between characters and which are
+// between words. Gaps inside characters are a dit long, and the rest split
+// into two groups, with the gaps between words being about 7/3 as long as the
+// gaps between characters. Farnsworth timing stretches the gaps between words,
+// and sometimes between characters too, but the groups stay apart. If there's
+// only one group, it's whichever it's closer to at the character speed.
+func classifyGaps(runs []run, dit float64) spacing {
+	var gaps []float64
+	for _, g := range lengths(runs, false) {
+		if g >= dit * 2 {
+			gaps = append(gaps, g)
+		}
+	}
+	sp := spacing{word: math.Inf(1), paragraph: math.Inf(1), unit: dit}
+	if len(gaps) == 0 {
+		return sp
+	}
+
+	ratio := math.Sqrt(7.0 / 3)
+	k, char, word := logSplit(gaps)
+	if k == 0 || k == len(gaps) || word < char * 1.6 {
+		// just the one group
+		all := geoMean(gaps)
+		if all < dit * 5 {
+			sp.word = all * ratio
+			sp.unit = max(dit, all / 3)
+			return sp
+		}
+		char, word = dit * 3, all
+	}
+	// the middle of each group, rather than the mean, so a few long
+	// pauses between paragraphs don't throw off the gaps between words
+	if k > 0 && k < len(gaps) && word >= char * 1.6 {
+		char, word = median(gaps[:k]), median(gaps[k:])
+	}
+	sp.word = math.Sqrt(char * word)
+	sp.paragraph = word * math.Sqrt(3)
+	sp.unit = max(dit, word / 7)
+	return sp
+}
+
+// decode turns the runs into text.
+func (sp spacing) decode(runs []run, dit float64) string {
+	var text, code strings.Builder
+	endChar := func() {
+		if code.Len() == 0 {
+			return
+		}
+		c, err := morsestrings.DecodeDotDash(code.String())
+		if err != nil {
+			c = "*"
+		}
+		text.WriteString(c)
+		code.Reset()
+	}
+
+	for _, r := range runs {
+		if r.on {
+			if r.n < dit * 2 {
+				code.WriteByte('.')
+			} else {
+				code.WriteByte('-')
+			}
+			continue
+		}
+		if r.n < dit * 2 {
+			continue
+		}
+		endChar()
+		switch {
+		case r.n >= sp.paragraph:
+			text.WriteByte('\n')
+		case r.n >= sp.word:
+			text.WriteByte(' ')
+		}
+	}
+	endChar()
+	// noise at the very end can leave a gap with nothing after it
+	return strings.TrimRight(text.String(), " \n")
+}
+
+// split finds where to split sorted values into two groups, keeping each
+// group as tight as it can be (this is Otsu's method). It returns the index
+// of the first value in the upper group, and the means of both groups.
+func split(sorted []float64) (int, float64, float64) {
+	n := len(sorted)
+	if n == 0 {
+		return 0, 0, 0
+	}
+	var total float64
+	for _, v := range sorted {
+		total += v
+	}
+
+	bestK, bestVar := n, -1.0
+	var sum float64
+	for k := 1; k < n; k++ {
+		sum += sorted[k - 1]
+		if sorted[k] == sorted[k - 1] {
+			continue
+		}
+		lo := sum / float64(k)
+		hi := (total - sum) / float64(n - k)
+		if v := float64(k) * float64(n - k) * (hi - lo) * (hi - lo); v > bestVar {
+			bestK, bestVar = k, v
+		}
+	}
+	if bestK == n {
+		mean := total / float64(n)
+		return n, mean, mean
+	}
+	var lo float64
+	for _, v := range sorted[:bestK] {
+		lo += v
+	}
+	return bestK, lo / float64(bestK), (total - lo) / float64(n - bestK)
+}
+
+// logSplit is split, but on a log scale, since lengths in Morse code go up by
+// multiples. The means it returns are geometric.
+func logSplit(sorted []float64) (int, float64, float64) {
+	logs := make([]float64, len(sorted))
+	for i, v := range sorted {
+		logs[i] = math.Log(v)
+	}
+	k, lo, hi := split(logs)
+	return k, math.Exp(lo), math.Exp(hi)
+}
+
+func mean(vs []float64) float64 {
+	var total float64
+	for _, v := range vs {
+		total += v
+	}
+	return total / float64(len(vs))
+}
+
+// median of sorted values
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n % 2 == 1 {
+		return sorted[n / 2]
+	}
+	return (sorted[n / 2 - 1] + sorted[n / 2]) / 2
+}
+
+func geoMean(vs []float64) float64 {
+	var total float64
+	for _, v := range vs {
+		total += math.Log(v)
+	}
+	return math.Exp(total / float64(len(vs)))
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+	"github.com/ctdk/morseudar/internal/audio"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func paragraphs(text string) [][]morsestrings.MorseString {
+	var paras [][]morsestrings.MorseString
+	for _, p := range strings.Split(text, "\n") {
+		paras = append(paras, []morsestrings.MorseString{morsestrings.StringToMorse(p)})
+	}
+	return paras
+}
+
+func render(t *testing.T, text string, wpm int, farn int, freq float64) []float64 {
+	t.Helper()
+	ma, err := audio.NewMorseAudio(freq, wpm, farn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stereo, err := ma.Samples(paragraphs(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]float64, len(stereo))
+	for i, st := range stereo {
+		samples[i] = st[0]
+	}
+	return samples
+}
+
+func checkResult(t *testing.T, res *Result, text string, wpm int, farn int, freq float64) {
+	t.Helper()
+	if res.Text != text {
+		t.Errorf("expected '%s', decoded '%s'", text, res.Text)
+	}
+	if res.WPM < wpm - 1 || res.WPM > wpm + 1 {
+		t.Errorf("expected about %d wpm, got %d", wpm, res.WPM)
+	}
+	if (farn == 0 && res.Farnsworth != 0) || res.Farnsworth < farn - 1 || res.Farnsworth > farn + 1 {
+		t.Errorf("expected a Farnsworth speed of about %d, got %d", farn, res.Farnsworth)
+	}
+	if math.Abs(res.Frequency - freq) > 15 {
+		t.Errorf("expected the tone to be about %.0f Hz, got %.0f", freq, res.Frequency)
+	}
+}
+
+func TestDecodeWAV(t *testing.T) {
+	tests := []struct {
+		text string
+		wpm int
+		freq float64
+	}{
+		{"cq cq de w1aw k", 18, 600},
+		{"qrl? 5nn", 20, 2200},
+		{"ur rst 599", 25, 3500},
+	}
+
+	for _, tt := range tests {
+		ma, err := audio.NewMorseAudio(tt.freq, tt.wpm, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn := filepath.Join(t.TempDir(), "cq.wav")
+		f, err := os.Create(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ma.Render(f, paragraphs(tt.text)); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		f, err = os.Open(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := DecodeWAV(f, Settings{})
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkResult(t, res, tt.text, tt.wpm, 0, tt.freq)
+	}
+}
+
+func TestDecodeSamples(t *testing.T) {
+	tests := []struct {
+		text string
+		wpm int
+		farn int
+		freq float64
+	}{
+		{"the quick brown fox jumps over the lazy dog 0123456789", 20, 0, 700},
+		{"paris paris", 5, 0, 500},
+		{"5nn tu 73", 35, 0, 900},
+		{"qrs? qrl? /", 25, 10, 650},
+		{"ttt eee", 15, 0, 700},
+		{"first paragraph\nsecond one", 15, 8, 800},
+	}
+	for _, tt := range tests {
+		res, err := DecodeSamples(render(t, tt.text, tt.wpm, tt.farn, tt.freq), audio.SampleRate(), Settings{})
+		if err != nil {
+			t.Errorf("error decoding '%s': %s", tt.text, err)
+			continue
+		}
+		checkResult(t, res, tt.text, tt.wpm, tt.farn, tt.freq)
+	}
+}
+
+func TestDecodeNoise(t *testing.T) {
+	text := "now is the time for all good men"
+	samples := render(t, text, 20, 12, 700)
+	rnd := rand.New(rand.NewSource(1))
+	// the noise is louder than the beeps, but spread over every frequency
+	for i := range samples {
+		samples[i] = (samples[i] + rnd.NormFloat64()) / 4
+	}
+	// and a bit of silence with noise before and after
+	pad := make([]float64, audio.SampleRate() / 2)
+	for i := range pad {
+		pad[i] = rnd.NormFloat64() / 4
+	}
+	samples = append(append(pad, samples...), pad...)
+
+	res, err := DecodeSamples(samples, audio.SampleRate(), Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, res, text, 20, 12, 700)
+
+	// another signal the decoder's told to ignore
+	other := render(t, "eeeeeeeeeeeeeeeeeeeeeeeeeeeee", 20, 0, 1200)
+	for i := range samples {
+		if i < len(other) {
+			samples[i] += other[i] / 2
+		}
+	}
+	res, err = DecodeSamples(samples, audio.SampleRate(), Settings{Frequency: 700})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, res, text, 20, 12, 700)
+}
+
+func TestNoMorse(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	noise := make([]float64, audio.SampleRate())
+	for i := range noise {
+		noise[i] = rnd.NormFloat64() / 4
+	}
+	for _, samples := range [][]float64{nil, make([]float64, audio.SampleRate()), noise} {
+		if _, err := DecodeSamples(samples, audio.SampleRate(), Settings{}); err != ErrNoMorse {
+			t.Errorf("expected ErrNoMorse, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package morse
+
+import (
+	"github.com/ctdk/morseudar/internal/morserrors"
+)
+
+// SpeedAdapter decides what speed the next line should be sent at, based on
+// how the answers have been going.
+type SpeedAdapter interface {
+	// Adapt takes the percentage correct of the latest answer and the
+	// current WPM and Farnsworth speeds, and returns the speeds to use for
+	// the next line.
+	Adapt(perc float64, wpm int, farn int) (int, int)
+}
+
+const (
+	defaultAdaptWindow = 5
+	adaptStep = 1
+	adaptMinWPM = 2
+	adaptMaxWPM = 60
+)
+
+// BandAdapter tries to keep the rolling average accuracy between a low and a
+// high target. If the average goes over the top of the band it speeds up, and
+// if it drops below the bottom it slows down. With AdjustFarnsworth set, it
+// leaves the character speed alone and changes the Farnsworth spacing instead.
+type BandAdapter struct {
+	Low float64
+	High float64
+	Window int
+	AdjustFarnsworth bool
+	recent []float64
+}
+
+// NewBandAdapter makes a new BandAdapter targeting accuracy between low and
+// high, given as fractions (so 0.85 rather than 85).
+func NewBandAdapter(low float64, high float64, adjustFarns bool) (*BandAdapter, error) {
+	if low < 0 || high > 1 || low >= high {
+		return nil, morserrors.InvalidValue
+	}
+	ba := &BandAdapter{Low: low, High: high, Window: defaultAdaptWindow, AdjustFarnsworth: adjustFarns}
+	ba.recent = make([]float64, 0, ba.Window)
+	return ba, nil
+}
+
+func (ba *BandAdapter) Adapt(perc float64, wpm int, farn int) (int, int) {
+	ba.recent = append(ba.recent, perc)
+	if len(ba.recent) > ba.Window {
+		ba.recent = ba.recent[1:]
+	}
+
+	// wait until there's a full window's worth of answers before deciding
+	// anything.
+	if len(ba.recent) < ba.Window {
+		return wpm, farn
+	}
+
+	var total float64
+	for _, p := range ba.recent {
+		total += p
+	}
+	avg := total / float64(len(ba.recent))
+
+	var step int
+	switch {
+	case avg > ba.High:
+		step = adaptStep
+	case avg < ba.Low:
+		step = -adaptStep
+	default:
+		return wpm, farn
+	}
+
+	newWPM, newFarn := wpm, farn
+	if ba.AdjustFarnsworth {
+		// No Farnsworth timing is the same as spacing at the full
+		// WPM, and spacing can't get any faster than that.
+		if newFarn == 0 {
+			newFarn = wpm
+		}
+		newFarn += step
+		if newFarn >= wpm {
+			newFarn = 0
+		} else if newFarn < adaptMinWPM {
+			newFarn = adaptMinWPM
+		}
+	} else {
+		newWPM += step
+		if newWPM < adaptMinWPM {
+			newWPM = adaptMinWPM
+		} else if newWPM > adaptMaxWPM {
+			newWPM = adaptMaxWPM
+		}
+	}
+
+	// Start over after changing speed, so the answers from the old speed
+	// don't keep pushing it further.
+	if newWPM != wpm || newFarn != farn {
+		ba.recent = ba.recent[:0]
+	}
+
+	return newWPM, newFarn
+}
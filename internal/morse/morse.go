@@ -19,9 +19,13 @@ package morse
 //go:generate stringer -type=MorseMode
 
 import (
+	"fmt"
 	"github.com/ctdk/morseudar/internal/audio"
+	"github.com/ctdk/morseudar/internal/morserrors"
 	"github.com/ctdk/morseudar/internal/morsestrings"
+	"io"
 	"math/rand"
+	"strings"
 	"time"
 )
 
@@ -41,9 +45,72 @@ const (
 	     // adding another.
 )
 
+// ParseMode turns the name of a mode (as given by its String method, but not
+// case sensitive) back into a MorseMode.
+func ParseMode(name string) (MorseMode, error) {
+	for mm := TextFile; mm <= Koch; mm++ {
+		if strings.EqualFold(mm.String(), name) {
+			return mm, nil
+		}
+	}
+	return 0, morserrors.InvalidValue
+}
+
+// ModeNames are the names of the modes the way they're given on the command
+// line, in the config file, and to the API. ParseMode's names are the ones
+// saved with the statistics.
+var ModeNames = []string{"text", "randomline", "codegroups", "codealnum", "codenumbers", "topwords", "qcodes", "chars"}
+
+// ModeFromName turns one of ModeNames (not case sensitive) into a MorseMode.
+// "text" and "randomline" are both TextFile, and are really just two names for
+// the same thing: the lines get sent at random either way, unless Sequential is
+// set (-r/--sequential on the command line).
+func ModeFromName(name string) (MorseMode, error) {
+	switch strings.ToLower(name) {
+	case "text", "randomline":
+		return TextFile, nil
+	case "codegroups":
+		return CodeGroup, nil
+	case "codealnum":
+		return CodeAlnum, nil
+	case "codenumbers":
+		return CodeNum, nil
+	case "topwords":
+		return TopWords, nil
+	case "qcodes":
+		return Qcode, nil
+	case "chars":
+		return MorseChar, nil
+	}
+	return 0, fmt.Errorf("unknown mode '%s': it should be one of %s", name, strings.Join(ModeNames, ", "))
+}
+
+// Name is the mode's name the way it's given on the command line. Koch mode
+// doesn't have one yet, so it gets its String name.
+func (mm MorseMode) Name() string {
+	switch mm {
+	case TextFile:
+		return "text"
+	case CodeGroup:
+		return "codegroups"
+	case CodeAlnum:
+		return "codealnum"
+	case CodeNum:
+		return "codenumbers"
+	case TopWords:
+		return "topwords"
+	case Qcode:
+		return "qcodes"
+	case MorseChar:
+		return "chars"
+	}
+	return strings.ToLower(mm.String())
+}
+
+// The speed and frequency used when they aren't given.
 const (
-	defaultFrequency = 700
-	defaultWPM = 10
+	DefaultFrequency = 700
+	DefaultWPM = 10
 )
 
 type Morse struct {
@@ -54,6 +121,12 @@ type Morse struct {
 	Sequential bool
 	EntireBlock bool
 	TestingMaterial MorseList
+	Adapter SpeedAdapter
+	Effects audio.Effects
+	// Silent, if set, keeps anything from actually being played. Sending
+	// returns as soon as the Morse has been worked out, without waiting
+	// or needing a sound card, which is handy for scripts and tests.
+	Silent bool
 	audio *audio.MorseAudio
 	src rand.Source
 	linesTested int
@@ -69,12 +142,20 @@ type MorseList interface {
 	Seek(int) error
 }
 
+// MorseBlock is implemented by testing material that can be sent all at once
+// as a block of paragraphs, rather than line by line.
+type MorseBlock interface {
+	GetParagraphs(int, int) ([][]morsestrings.MorseString, error)
+	LineRange(int, int) (int, int, error)
+	SourceLine(int) int
+}
+
 func New(mode MorseMode, wpm int, farn int, freq float64, seq bool, entire bool, randSeed int64) (*Morse, error) {
 	m := new(Morse)
 	m.Mode = mode
 
 	if wpm == 0 {
-		m.WPM = defaultWPM
+		m.WPM = DefaultWPM
 	} else {
 		m.WPM = wpm
 	}
@@ -85,7 +166,7 @@ func New(mode MorseMode, wpm int, farn int, freq float64, seq bool, entire bool,
 	}
 
 	if freq == 0 {
-		m.Frequency = float64(defaultFrequency)
+		m.Frequency = float64(DefaultFrequency)
 	} else {
 		m.Frequency = freq
 	}
@@ -93,11 +174,9 @@ func New(mode MorseMode, wpm int, farn int, freq float64, seq bool, entire bool,
 	m.Sequential = seq
 	m.EntireBlock = entire
 
-	ma, err := audio.NewMorseAudio(m.Frequency, m.WPM, m.Farnsworth)
-	if err != nil {
+	if err := m.setAudio(); err != nil {
 		return nil, err
 	}
-	m.audio = ma
 
 	if randSeed == 0 {
 		randSeed = time.Now().UnixNano()
@@ -107,10 +186,122 @@ func New(mode MorseMode, wpm int, farn int, freq float64, seq bool, entire bool,
 	return m, nil
 }
 
+// SetSpeed changes the WPM and Farnsworth timing in the middle of a session.
+// As with New, Farnsworth timing is ignored if it isn't slower than the WPM.
+func (m *Morse) SetSpeed(wpm int, farn int) error {
+	if wpm <= 0 || farn < 0 {
+		return morserrors.InvalidValue
+	}
+	if farn >= wpm {
+		farn = 0
+	}
+	oldWPM, oldFarn := m.WPM, m.Farnsworth
+	m.WPM = wpm
+	m.Farnsworth = farn
+
+	if err := m.setAudio(); err != nil {
+		m.WPM, m.Farnsworth = oldWPM, oldFarn
+		return err
+	}
+	return nil
+}
+
+// SetFrequency changes the frequency of the beeps in the middle of a session.
+func (m *Morse) SetFrequency(freq float64) error {
+	if freq <= 0 {
+		return morserrors.InvalidValue
+	}
+	oldFreq := m.Frequency
+	m.Frequency = freq
+
+	if err := m.setAudio(); err != nil {
+		m.Frequency = oldFreq
+		return err
+	}
+	return nil
+}
+
+// Adapt passes the percentage correct of the latest answer along to the speed
+// adapter, if there is one, and changes the speed if it says to. It returns
+// true if the speed changed.
+func (m *Morse) Adapt(perc float64) (bool, error) {
+	if m.Adapter == nil {
+		return false, nil
+	}
+	wpm, farn := m.Adapter.Adapt(perc, m.WPM, m.Farnsworth)
+	if wpm == m.WPM && farn == m.Farnsworth {
+		return false, nil
+	}
+	if err := m.SetSpeed(wpm, farn); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetEffects changes how the beeps sound.
+func (m *Morse) SetEffects(fx audio.Effects) error {
+	if err := fx.Validate(); err != nil {
+		return err
+	}
+	oldFx := m.Effects
+	m.Effects = fx
+
+	if err := m.setAudio(); err != nil {
+		m.Effects = oldFx
+		return err
+	}
+	return nil
+}
+
+// setAudio (re)builds the audio buffers with the current settings.
+func (m *Morse) setAudio() error {
+	ma, err := audio.NewMorseAudioEffects(m.Frequency, m.WPM, m.Farnsworth, m.Effects)
+	if err != nil {
+		return err
+	}
+	m.audio = ma
+	return nil
+}
+
 func (m *Morse) Send(ms morsestrings.MorseString) error {
+	if m.Silent {
+		_, err := m.audio.Elements(ms)
+		return err
+	}
 	return m.audio.SendMessage(ms)
 }
 
+// Elements lays out the beeps and silences of a line at the current speed,
+// for playing it somewhere other than the speaker.
+func (m *Morse) Elements(ms morsestrings.MorseString) ([]audio.Element, error) {
+	return m.audio.Elements(ms)
+}
+
+// Duration is how long sending a line takes at the current speed, including
+// the space after it.
+func (m *Morse) Duration(ms morsestrings.MorseString) (time.Duration, error) {
+	els, err := m.Elements(ms)
+	if err != nil {
+		return 0, err
+	}
+	var dur time.Duration
+	for _, e := range els {
+		dur += e.Dur
+	}
+	return dur, nil
+}
+
+// Stop cuts off any Morse being sent, and keeps any more from being sent.
+// It's safe to call from another goroutine while something's being sent.
+func (m *Morse) Stop() {
+	audio.Stop()
+}
+
+// Close stops sending and closes the speaker.
+func (m *Morse) Close() {
+	audio.Close()
+}
+
 func (m *Morse) Src() rand.Source {
 	return m.src
 }
@@ -122,3 +313,48 @@ func (m *Morse) GetMorse() (morsestrings.MorseString, error) {
 
 	return m.TestingMaterial.RandomLine()
 }
+
+// GetBlock returns the lines from start up to end from the testing material,
+// grouped into paragraphs, if the testing material supports it.
+func (m *Morse) GetBlock(start int, end int) ([][]morsestrings.MorseString, error) {
+	mb, ok := m.TestingMaterial.(MorseBlock)
+	if !ok {
+		return nil, morserrors.NotApplicable
+	}
+	return mb.GetParagraphs(start, end)
+}
+
+// BlockRange converts the lines first through last of the original text file
+// into the start and end arguments for GetBlock, since blank lines aren't kept.
+func (m *Morse) BlockRange(first int, last int) (int, int, error) {
+	mb, ok := m.TestingMaterial.(MorseBlock)
+	if !ok {
+		return 0, 0, morserrors.NotApplicable
+	}
+	return mb.LineRange(first, last)
+}
+
+// SourceLine returns which line of the original text file line i of the
+// testing material came from, or 0 if that can't be told.
+func (m *Morse) SourceLine(i int) int {
+	mb, ok := m.TestingMaterial.(MorseBlock)
+	if !ok {
+		return 0
+	}
+	return mb.SourceLine(i)
+}
+
+// SendBlock sends a block of paragraphs in one continuous stream.
+func (m *Morse) SendBlock(paras [][]morsestrings.MorseString) error {
+	if m.Silent {
+		_, err := m.audio.ParagraphElements(paras)
+		return err
+	}
+	return m.audio.SendParagraphs(paras)
+}
+
+// Render writes a block of paragraphs to w as a WAV file, timed the same way
+// SendBlock would send them.
+func (m *Morse) Render(w io.WriteSeeker, paras [][]morsestrings.MorseString) error {
+	return m.audio.Render(w, paras)
+}
@@ -19,7 +19,9 @@ package morse
 import (
 	"github.com/ctdk/morseudar/internal/audio"
 	"github.com/ctdk/morseudar/internal/morsestrings"
+	"strings"
 	"testing"
+	"time"
 )
 
 const randSeed = 12345
@@ -79,3 +81,163 @@ func TestMorseObject(t *testing.T) {
 	}
 	// TODO: Test the actual object properties or something
 }
+
+func TestMorseSilent(t *testing.T) {
+	m, err := New(TextFile, 5, 0, 660, false, false, randSeed)
+	if err != nil {
+		t.Fatalf("error creating morse object: %s", err.Error())
+	}
+	m.Silent = true
+
+	// this would take most of a minute at 5 wpm if it were played
+	began := time.Now()
+	if err = m.Send(morsestrings.StringToMorse("the quick brown fox jumps over the lazy dog")); err != nil {
+		t.Errorf("sending silently didn't work: %v", err)
+	}
+	if err = m.SendBlock([][]morsestrings.MorseString{{morsestrings.StringToMorse("cq cq")}}); err != nil {
+		t.Errorf("sending a block silently didn't work: %v", err)
+	}
+	if took := time.Since(began); took > time.Second {
+		t.Errorf("sending silently should return right away, but took %s", took)
+	}
+}
+
+func TestMorseSetSpeed(t *testing.T) {
+	m, err := New(TextFile, 20, 10, 660, false, false, randSeed)
+	if err != nil {
+		t.Fatalf("error creating morse object: %s", err.Error())
+	}
+
+	if err = m.SetSpeed(25, m.Farnsworth); err != nil {
+		t.Errorf("error changing speed: %v", err)
+	}
+	if m.WPM != 25 || m.Farnsworth != 10 {
+		t.Errorf("speed should have been 25/10, got %d/%d", m.WPM, m.Farnsworth)
+	}
+
+	// Farnsworth timing faster than the WPM gets dropped
+	if err = m.SetSpeed(8, m.Farnsworth); err != nil {
+		t.Errorf("error changing speed: %v", err)
+	}
+	if m.WPM != 8 || m.Farnsworth != 0 {
+		t.Errorf("speed should have been 8/0, got %d/%d", m.WPM, m.Farnsworth)
+	}
+
+	if err = m.SetSpeed(0, 0); err == nil {
+		t.Errorf("setting the speed to 0 wpm should have failed")
+	}
+	if m.WPM != 8 {
+		t.Errorf("a failed speed change should have left the speed alone, but it's %d", m.WPM)
+	}
+
+	if err = m.SetFrequency(550); err != nil {
+		t.Errorf("error changing frequency: %v", err)
+	}
+	if m.Frequency != 550 {
+		t.Errorf("frequency should have been 550, got %f", m.Frequency)
+	}
+	if err = m.SetFrequency(30000); err == nil {
+		t.Errorf("setting the frequency above the Nyquist limit should have failed")
+	}
+	if m.Frequency != 550 {
+		t.Errorf("a failed frequency change should have left the frequency alone, but it's %f", m.Frequency)
+	}
+}
+
+func TestBandAdapter(t *testing.T) {
+	if _, err := NewBandAdapter(0.95, 0.85, false); err == nil {
+		t.Errorf("creating a band adapter with the low and high targets backwards should have failed")
+	}
+
+	ba, err := NewBandAdapter(0.85, 0.95, false)
+	if err != nil {
+		t.Fatalf("error creating band adapter: %v", err)
+	}
+
+	wpm, farn := 15, 0
+	for i := 0; i < ba.Window - 1; i++ {
+		wpm, farn = ba.Adapt(1.0, wpm, farn)
+	}
+	if wpm != 15 {
+		t.Errorf("speed shouldn't change before the window fills up, but went to %d", wpm)
+	}
+	wpm, farn = ba.Adapt(1.0, wpm, farn)
+	if wpm != 15 + adaptStep {
+		t.Errorf("speed should have gone up to %d after a window of perfect answers, got %d", 15 + adaptStep, wpm)
+	}
+
+	// in the band, nothing should change
+	for i := 0; i < ba.Window; i++ {
+		wpm, farn = ba.Adapt(0.9, wpm, farn)
+	}
+	if wpm != 15 + adaptStep {
+		t.Errorf("speed shouldn't change when accuracy's in the band, but went to %d", wpm)
+	}
+
+	for i := 0; i < ba.Window; i++ {
+		wpm, farn = ba.Adapt(0.5, wpm, farn)
+	}
+	if wpm != 15 || farn != 0 {
+		t.Errorf("speed should have dropped back to 15/0, got %d/%d", wpm, farn)
+	}
+}
+
+func TestBandAdapterFarnsworth(t *testing.T) {
+	ba, err := NewBandAdapter(0.85, 0.95, true)
+	if err != nil {
+		t.Fatalf("error creating band adapter: %v", err)
+	}
+
+	wpm, farn := 20, 0
+	for i := 0; i < ba.Window; i++ {
+		wpm, farn = ba.Adapt(0.4, wpm, farn)
+	}
+	if wpm != 20 || farn != 20 - adaptStep {
+		t.Errorf("Farnsworth spacing should have slowed to %d with the speed left at 20, got %d/%d", 20 - adaptStep, wpm, farn)
+	}
+
+	for i := 0; i < ba.Window; i++ {
+		wpm, farn = ba.Adapt(1.0, wpm, farn)
+	}
+	if wpm != 20 || farn != 0 {
+		t.Errorf("Farnsworth spacing catching up to the WPM should turn it off, got %d/%d", wpm, farn)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for mm := TextFile; mm <= Koch; mm++ {
+		p, err := ParseMode(mm.String())
+		if err != nil {
+			t.Errorf("error parsing mode '%s': %v", mm, err)
+		}
+		if p != mm {
+			t.Errorf("parsing '%s' gave %s", mm, p)
+		}
+	}
+	if p, err := ParseMode("topwords"); err != nil || p != TopWords {
+		t.Errorf("parsing 'topwords' should have given TopWords, got %s (%v)", p, err)
+	}
+	if _, err := ParseMode("bork"); err == nil {
+		t.Errorf("parsing an unknown mode should have failed")
+	}
+}
+
+func TestModeFromName(t *testing.T) {
+	for _, name := range ModeNames {
+		mm, err := ModeFromName(name)
+		if err != nil {
+			t.Errorf("error with mode name '%s': %v", name, err)
+			continue
+		}
+		// randomline is just text sent out of order
+		if want := strings.Replace(name, "randomline", "text", 1); mm.Name() != want {
+			t.Errorf("'%s' came back as '%s'", name, mm.Name())
+		}
+	}
+	if mm, err := ModeFromName("QCodes"); err != nil || mm != Qcode {
+		t.Errorf("mode names shouldn't be case sensitive, got %s (%v)", mm, err)
+	}
+	if _, err := ModeFromName("MorseChar"); err == nil {
+		t.Errorf("the names saved with the stats aren't mode names")
+	}
+}
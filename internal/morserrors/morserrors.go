@@ -24,3 +24,5 @@ var EOF = errors.New("EOF")
 var NoText = errors.New("no text in text block")
 var OutOfRange = errors.New("position out of range")
 var NotApplicable = errors.New("not applicable to this type")
+var InvalidValue = errors.New("invalid value")
+var InvalidPattern = errors.New("invalid Morse pattern")
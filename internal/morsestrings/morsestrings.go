@@ -17,8 +17,12 @@
 package morsestrings
 
 import (
+	"fmt"
 	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morserrors"
+	"slices"
 	"strings"
+	"unicode"
 )
 
 type MorseChar string
@@ -83,8 +87,32 @@ var Alphabet = map[rune]MorseChar {
 	'@': ".--.-.",
 }
 
+// Prosigns are the usual procedural signals, which are sent as their letters
+// run together. Several of them are the same as punctuation (AR is +, BT is =,
+// KN is (, and AS is &), and decode as the punctuation unless they're marked
+// as prosigns. Each one has its own pattern, so they all decode back to the
+// same name (SN, the same as VE, is left out for that reason).
+var Prosigns = []string{"ar", "as", "bk", "bt", "cl", "ct", "hh", "kn", "sk", "sos", "ve"}
+
 const wordJoin = " / "
 
+// the alphabet and prosigns backwards, for decoding
+var fromMorse = make(map[MorseChar]rune, len(Alphabet))
+var fromProsign = make(map[MorseChar]string, len(Prosigns))
+
+func init() {
+	for r, mc := range Alphabet {
+		fromMorse[mc] = r
+	}
+	for _, p := range Prosigns {
+		var sb strings.Builder
+		for _, c := range p {
+			sb.WriteString(string(Alphabet[c]))
+		}
+		fromProsign[MorseChar(sb.String())] = p
+	}
+}
+
 // hopefully this isn't overdoing keeping things private
 
 func (mw *MorseWord) IsProsign() bool {
@@ -134,13 +162,58 @@ func StringToMorse(str string) MorseString {
 	return MorseString(m)
 }
 
+// UnknownChars returns the characters in str that don't have a Morse code, and
+// that StringToMorse would skip, each one once in the order they turn up.
+// Spaces and the ~ marking prosigns don't count.
+func UnknownChars(str string) []rune {
+	var unknown []rune
+	seen := make(map[rune]bool)
+	for _, c := range strings.ToLower(str) {
+		if c == ' ' || c == '~' || seen[c] {
+			continue
+		}
+		if _, ok := Alphabet[c]; !ok {
+			unknown = append(unknown, c)
+			seen[c] = true
+		}
+	}
+	return unknown
+}
+
+// Encode is StringToMorse for text that hasn't been tidied up. The spacing is
+// evened out, and characters without a Morse code are left out and returned,
+// each one once in the order they turn up, so they can be complained about.
+func Encode(str string) (MorseString, []rune) {
+	unknown := UnknownChars(str)
+	if len(unknown) > 0 {
+		str = strings.Map(func(r rune) rune {
+			if slices.Contains(unknown, unicode.ToLower(r)) {
+				return -1
+			}
+			return r
+		}, str)
+	}
+	return StringToMorse(strings.Join(strings.Fields(str), " ")), unknown
+}
+
 // DotDashString spits out the encoded morse characters as dots and dashes
 func (ms MorseString) DotDashString() string {
+	return ms.dotDash(false)
+}
+
+// MarkedDotDashString is DotDashString with prosigns wrapped in tildes and
+// their characters kept apart, like ~... -.-~, so they decode as the same
+// prosign even when they're also punctuation, or aren't one of Prosigns.
+func (ms MorseString) MarkedDotDashString() string {
+	return ms.dotDash(true)
+}
+
+func (ms MorseString) dotDash(markProsigns bool) string {
 	str := make([]string, len(ms))
 
 	for i, m := range ms {
 		var joiner string
-		if !m.prosign {
+		if !m.prosign || markProsigns {
 			joiner = " "
 		} else {
 			joiner = ""
@@ -152,6 +225,9 @@ func (ms MorseString) DotDashString() string {
 		}
 
 		w := strings.Join(wordAssemble, joiner)
+		if m.prosign && markProsigns && w != "" {
+			w = "~" + w + "~"
+		}
 		str[i] = w
 	}
 
@@ -182,3 +258,83 @@ func (ms MorseString) RawString() string {
 func (ms MorseString) Compare(attempted string) float64 {
 	return compare.CompareStrings(ms.RawString(), attempted)
 }
+
+// DecodeDotDash turns dots and dashes back into text. Characters are separated
+// by spaces and words by slashes, the way DotDashString writes them. Prosigns
+// come back wrapped in tildes, like ~sk~, the way StringToMorse takes them.
+// Characters wrapped in tildes, the way MarkedDotDashString writes them, are
+// always a prosign; if they're run together, like ~...-.-~, they have to be
+// one of Prosigns. A pattern that isn't a character is decoded as a prosign
+// if it's one of Prosigns too.
+func DecodeDotDash(dotdash string) (string, error) {
+	words := strings.Split(dotdash, "/")
+	text := make([]string, 0, len(words))
+
+	for _, w := range words {
+		chars := strings.Fields(w)
+		if len(chars) == 0 {
+			continue
+		}
+		var sb strings.Builder
+		for i := 0; i < len(chars); i++ {
+			c := chars[i]
+			if !strings.HasPrefix(c, "~") {
+				r, ok := fromMorse[MorseChar(c)]
+				if ok {
+					sb.WriteRune(r)
+					continue
+				}
+				p, ok := fromProsign[MorseChar(c)]
+				if !ok {
+					return "", fmt.Errorf("%w '%s'", morserrors.InvalidPattern, c)
+				}
+				sb.WriteString("~" + p + "~")
+				continue
+			}
+
+			// a marked prosign, which goes on until the closing ~
+			end := i
+			for ; end < len(chars); end++ {
+				if strings.HasSuffix(chars[end], "~") && (end > i || len(chars[end]) > 1) {
+					break
+				}
+			}
+			if end == len(chars) {
+				return "", fmt.Errorf("%w '%s': the prosign isn't closed with a ~", morserrors.InvalidPattern, strings.Join(chars[i:], " "))
+			}
+			p, err := decodeProsign(chars[i:end + 1])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString("~" + p + "~")
+			i = end
+		}
+		text = append(text, sb.String())
+	}
+
+	return strings.Join(text, " "), nil
+}
+
+// decodeProsign decodes the characters of a marked prosign, tildes and all.
+func decodeProsign(chars []string) (string, error) {
+	marked := strings.Join(chars, " ")
+	inner := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(marked, "~"), "~"))
+	if len(inner) == 1 {
+		if p, ok := fromProsign[MorseChar(inner[0])]; ok {
+			return p, nil
+		}
+	}
+	if len(inner) == 0 {
+		return "", fmt.Errorf("%w '%s'", morserrors.InvalidPattern, marked)
+	}
+
+	var sb strings.Builder
+	for _, c := range inner {
+		r, ok := fromMorse[MorseChar(c)]
+		if !ok {
+			return "", fmt.Errorf("%w '%s' in prosign '%s'", morserrors.InvalidPattern, c, marked)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package morsestrings
+
+import (
+	"errors"
+	"github.com/ctdk/morseudar/internal/morserrors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeDotDash(t *testing.T) {
+	for _, s := range []string{"cq de w1aw", "the quick brown fox", "73, 5nn?"} {
+		got, err := DecodeDotDash(StringToMorse(s).DotDashString())
+		if err != nil {
+			t.Errorf("error decoding '%s': %s", s, err)
+			continue
+		}
+		if got != s {
+			t.Errorf("'%s' decoded as '%s'", s, got)
+		}
+	}
+
+	// extra spaces and slashes don't matter
+	if got, _ := DecodeDotDash("  .... ..  //  - .... . .-. .  "); got != "hi there" {
+		t.Errorf("expected 'hi there', got '%s'", got)
+	}
+
+	if _, err := DecodeDotDash(".... ......."); !errors.Is(err, morserrors.InvalidPattern) {
+		t.Errorf("expected InvalidPattern, got %v", err)
+	}
+	if _, err := DecodeDotDash("~.--...~"); !errors.Is(err, morserrors.InvalidPattern) {
+		t.Errorf("expected InvalidPattern for a run together prosign that isn't one, got %v", err)
+	}
+}
+
+func TestProsigns(t *testing.T) {
+	ms := StringToMorse("cq de w1aw ~ar~ ~sk~")
+	if got, want := ms.MarkedDotDashString(), "-.-. --.- / -.. . / .-- .---- .- .-- / ~.- .-.~ / ~... -.-~"; got != want {
+		t.Errorf("expected '%s', got '%s'", want, got)
+	}
+
+	tests := []struct {
+		dotdash string
+		want string
+	}{
+		// marked prosigns always come back as prosigns
+		{ms.MarkedDotDashString(), "cq de w1aw ~ar~ ~sk~"},
+		// unmarked ones do unless they're punctuation
+		{ms.DotDashString(), "cq de w1aw + ~sk~"},
+		{"-.- / -.--. / ~-.--.~", "k ( ~kn~"},
+		// prosigns that aren't in the list still decode with their
+		// characters kept apart
+		{"~.- -...~ ~-.-. --.-~", "~ab~~cq~"},
+		{"~ -.-. --.- ~ / ~.~", "~cq~ ~e~"},
+	}
+	for _, tt := range tests {
+		got, err := DecodeDotDash(tt.dotdash)
+		if err != nil {
+			t.Errorf("error decoding '%s': %s", tt.dotdash, err)
+		} else if got != tt.want {
+			t.Errorf("'%s' decoded as '%s', expected '%s'", tt.dotdash, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"~.- -...", "~.- ......~", "~ ~"} {
+		if _, err := DecodeDotDash(bad); !errors.Is(err, morserrors.InvalidPattern) {
+			t.Errorf("expected InvalidPattern for '%s', got %v", bad, err)
+		}
+	}
+}
+
+func TestProsignsRoundTrip(t *testing.T) {
+	for _, p := range Prosigns {
+		ms := StringToMorse("~" + p + "~")
+		for _, dotdash := range []string{ms.MarkedDotDashString(), strings.Trim(ms.DotDashString(), " ")} {
+			got, err := DecodeDotDash("~" + strings.Trim(dotdash, "~") + "~")
+			if err != nil {
+				t.Errorf("error decoding ~%s~ from '%s': %s", p, dotdash, err)
+			} else if got != "~" + p + "~" {
+				t.Errorf("~%s~ came back from '%s' as '%s'", p, dotdash, got)
+			}
+		}
+	}
+}
+
+func TestUnknownChars(t *testing.T) {
+	if u := UnknownChars("cq de ~sk~ w1aw"); len(u) != 0 {
+		t.Errorf("expected no unknown characters, got %q", u)
+	}
+	if u := UnknownChars("50% off! 50%"); !reflect.DeepEqual(u, []rune{'%', '!'}) {
+		t.Errorf("expected '%%' and '!' to be unknown, got %q", u)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"github.com/ctdk/morseudar/internal/stats"
+)
+
+// CharStat counts how many times a character was sent, and how many of those
+// times it was copied correctly.
+type CharStat struct {
+	Sent int
+	Correct int
+}
+
+// Accuracy returns the fraction of the times the character was sent that it
+// was copied correctly.
+func (cs CharStat) Accuracy() float64 {
+	if cs.Sent == 0 {
+		return 0
+	}
+	return float64(cs.Correct) / float64(cs.Sent)
+}
+
+// CharStats goes through the answers saved with each session and works out how
+// well each character has been copied. Sessions saved before answers were kept
+// don't count.
+func CharStats(sums []stats.Summary) map[rune]*CharStat {
+	cs := make(map[rune]*CharStat)
+	for _, s := range sums {
+		for _, a := range s.Answers {
+			AddCharStats(cs, a.Original, a.Response)
+		}
+	}
+	return cs
+}
+
+// AddCharStats lines up the response with the original and adds how each
+// character in the original was copied to the character stats. Spaces aren't
+// counted.
+func AddCharStats(cs map[rune]*CharStat, orig string, resp string) {
+	chars, copied := stats.CharResults(orig, resp)
+	for i, r := range chars {
+		c, ok := cs[r]
+		if !ok {
+			c = new(CharStat)
+			cs[r] = c
+		}
+		c.Sent++
+		if copied[i] {
+			c.Correct++
+		}
+	}
+}
@@ -0,0 +1,338 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/morse"
+	"html"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HTML output for reports. Everything, charts included, goes into the one file
+// with no outside scripts or stylesheets, so it can be mailed around and opened
+// offline. The charts are plain SVG built up here.
+
+const (
+	chartW = 800
+	chartH = 300
+	chartMargin = 45
+	heatCell = 40
+	heatPerRow = 13
+	calCell = 12
+	// the characters in the heat map, in the order they show up
+	heatChars = "abcdefghijklmnopqrstuvwxyz0123456789.,?=/:()+-&\"'@"
+)
+
+var seriesColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"}
+var calColors = []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+
+type point struct {
+	t time.Time
+	v float64
+}
+
+type series struct {
+	name string
+	color string
+	points []point
+}
+
+type htmlGroup struct {
+	Mode morse.MorseMode
+	Wpm int
+	Sessions int
+	Lines int
+	Last float64
+	Avg float64
+	Best float64
+	Trend float64
+}
+
+type htmlReport struct {
+	*Report
+	Generated time.Time
+	First time.Time
+	Last time.Time
+	Groups []htmlGroup
+	AccuracyChart template.HTML
+	SpeedChart template.HTML
+	HeatMap template.HTML
+	Calendars []template.HTML
+}
+
+var htmlTmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"perc": func(f float64) string { return fmt.Sprintf("%.1f%%", f * 100) },
+	"date": func(t time.Time) string { return t.Format(dateFmt) },
+	"trend": func(f float64) string { return fmt.Sprintf("%+.1f", f * 100) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>morseudar progress report for {{.Username}}</title>
+<style>
+body { font-family: sans-serif; max-width: 900px; margin: 2em auto; color: #222; }
+h1, h2 { font-weight: normal; }
+table { border-collapse: collapse; }
+th, td { padding: 0.25em 0.75em; text-align: right; border-bottom: 1px solid #ddd; }
+th:first-child, td:first-child { text-align: left; }
+svg text { font-family: sans-serif; font-size: 12px; fill: #444; }
+.note { color: #666; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Progress report for {{.Username}}</h1>
+{{if .Sessions -}}
+<p>{{len .Sessions}} sessions and {{.Lines}} lines from {{date .First}} to {{date .Last}}. Generated {{date .Generated}}.</p>
+
+<h2>Accuracy by mode</h2>
+{{.AccuracyChart}}
+
+<h2>Speed</h2>
+{{.SpeedChart}}
+
+<h2>By mode and speed</h2>
+<table>
+<tr><th>Mode</th><th>WPM</th><th>Sessions</th><th>Lines</th><th>Last</th><th>Avg of {{.Window}}</th><th>Best</th><th>Trend</th></tr>
+{{range .Groups -}}
+<tr><td>{{.Mode}}</td><td>{{.Wpm}}</td><td>{{.Sessions}}</td><td>{{.Lines}}</td><td>{{perc .Last}}</td><td>{{perc .Avg}}</td><td>{{perc .Best}}</td><td>{{trend .Trend}}</td></tr>
+{{end -}}
+</table>
+<p class="note">Trend is the change in accuracy per session, in percentage points.</p>
+
+<h2>Personal bests</h2>
+<ul>
+{{range .Bests -}}
+<li>{{.Description}}: {{.Summary.Wpm}} wpm at {{perc .Summary.AvgPerc}} on {{date .Summary.Date}}.</li>
+{{end -}}
+</ul>
+
+{{if .Regressions -}}
+<h2>Regressions</h2>
+<ul>
+{{range .Regressions -}}
+<li>{{.Group.Mode}} at {{.Group.Wpm}} wpm on {{date .Summary.Date}}: {{perc .Summary.AvgPerc}}, down from an average of {{perc .Previous}} before it.</li>
+{{end -}}
+</ul>
+{{end -}}
+
+<h2>Characters</h2>
+{{.HeatMap}}
+<p class="note">How often each character was copied correctly, from the answers saved with each session. Grey characters haven't come up yet.</p>
+
+<h2>Practice calendar</h2>
+{{range .Calendars}}{{.}}
+{{end -}}
+{{else -}}
+<p>No sessions saved yet.</p>
+{{end -}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the report out as a single self-contained HTML file.
+func (r *Report) WriteHTML(w io.Writer) error {
+	hr := &htmlReport{Report: r, Generated: time.Now()}
+
+	if len(r.Sessions) > 0 {
+		hr.First = r.Sessions[0].Date
+		hr.Last = r.Sessions[len(r.Sessions)-1].Date
+
+		for _, g := range r.Groups {
+			acc := g.Accuracy()
+			ma := MovingAverage(acc, r.Window)
+			hr.Groups = append(hr.Groups, htmlGroup{Mode: g.Mode, Wpm: g.Wpm, Sessions: len(g.Summaries), Lines: g.Lines(), Last: acc[len(acc)-1], Avg: ma[len(ma)-1], Best: g.Best().AvgPerc, Trend: Trend(acc)})
+		}
+
+		hr.AccuracyChart = lineChart(r.accuracySeries(), 0, 100, "%")
+		hr.SpeedChart = r.speedChart()
+		hr.HeatMap = heatMap(CharStats(r.Sessions))
+		hr.Calendars = r.calendars()
+	}
+
+	return htmlTmpl.Execute(w, hr)
+}
+
+// accuracySeries makes a line for each mode's accuracy over time.
+func (r *Report) accuracySeries() []series {
+	byMode := make(map[morse.MorseMode]*series)
+	modes := make([]morse.MorseMode, 0)
+	for _, s := range r.Sessions {
+		sr, ok := byMode[s.Mode]
+		if !ok {
+			sr = &series{name: s.Mode.String()}
+			byMode[s.Mode] = sr
+			modes = append(modes, s.Mode)
+		}
+		sr.points = append(sr.points, point{s.Date, s.AvgPerc * 100})
+	}
+	sort.Slice(modes, func(i, j int) bool { return modes[i] < modes[j] })
+
+	srs := make([]series, len(modes))
+	for i, mm := range modes {
+		srs[i] = *byMode[mm]
+		srs[i].color = seriesColors[i % len(seriesColors)]
+	}
+	return srs
+}
+
+func (r *Report) speedChart() template.HTML {
+	sr := series{name: "WPM", color: seriesColors[0]}
+	lo, hi := r.Sessions[0].Wpm, r.Sessions[0].Wpm
+	for _, s := range r.Sessions {
+		sr.points = append(sr.points, point{s.Date, float64(s.Wpm)})
+		lo = min(lo, s.Wpm)
+		hi = max(hi, s.Wpm)
+	}
+	return lineChart([]series{sr}, float64(max(0, lo - 2)), float64(hi + 2), " wpm")
+}
+
+// lineChart draws the series as lines on an SVG chart, with time along the
+// bottom.
+func lineChart(srs []series, yMin float64, yMax float64, unit string) template.HTML {
+	var tMin, tMax time.Time
+	for _, sr := range srs {
+		for _, p := range sr.points {
+			if tMin.IsZero() || p.t.Before(tMin) {
+				tMin = p.t
+			}
+			if p.t.After(tMax) {
+				tMax = p.t
+			}
+		}
+	}
+	span := tMax.Sub(tMin)
+	plotW := float64(chartW - chartMargin * 2)
+	plotH := float64(chartH - chartMargin * 2)
+
+	x := func(t time.Time) float64 {
+		if span == 0 {
+			return chartMargin + plotW / 2
+		}
+		return chartMargin + plotW * float64(t.Sub(tMin)) / float64(span)
+	}
+	y := func(v float64) float64 {
+		if yMax <= yMin {
+			return chartMargin + plotH / 2
+		}
+		return chartMargin + plotH * (1 - (v - yMin) / (yMax - yMin))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, chartW, chartH, chartW, chartH)
+
+	// grid lines
+	for i := 0; i <= 4; i++ {
+		v := yMin + (yMax - yMin) * float64(i) / 4
+		fmt.Fprintf(&sb, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`, chartMargin, y(v), chartW - chartMargin, y(v))
+		fmt.Fprintf(&sb, `<text x="%d" y="%.1f" text-anchor="end">%.0f%s</text>`, chartMargin - 5, y(v) + 4, v, html.EscapeString(unit))
+	}
+	fmt.Fprintf(&sb, `<text x="%d" y="%d">%s</text>`, chartMargin, chartH - chartMargin + 18, tMin.Format(dateFmt))
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%s</text>`, chartW - chartMargin, chartH - chartMargin + 18, tMax.Format(dateFmt))
+
+	for i, sr := range srs {
+		pts := make([]string, len(sr.points))
+		for j, p := range sr.points {
+			pts[j] = fmt.Sprintf("%.1f,%.1f", x(p.t), y(p.v))
+		}
+		fmt.Fprintf(&sb, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, sr.color, strings.Join(pts, " "))
+		for _, p := range sr.points {
+			fmt.Fprintf(&sb, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s %s: %.1f%s</title></circle>`, x(p.t), y(p.v), sr.color, html.EscapeString(sr.name), p.t.Format(dateFmt), p.v, html.EscapeString(unit))
+		}
+
+		// legend
+		if len(srs) > 1 {
+			lx := chartMargin + i * 120
+			fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`, lx, chartH - 18, sr.color)
+			fmt.Fprintf(&sb, `<text x="%d" y="%d">%s</text>`, lx + 14, chartH - 9, html.EscapeString(sr.name))
+		}
+	}
+
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+// heatMap draws a grid of the characters, colored from red for poorly copied
+// to green for well copied.
+func heatMap(cs map[rune]*CharStat) template.HTML {
+	chars := []rune(heatChars)
+	rows := (len(chars) + heatPerRow - 1) / heatPerRow
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`, heatCell * heatPerRow, heatCell * rows)
+	for i, r := range chars {
+		cx := (i % heatPerRow) * heatCell
+		cy := (i / heatPerRow) * heatCell
+		color := "#ddd"
+		tip := fmt.Sprintf("%c: not sent yet", r)
+		if c, ok := cs[r]; ok && c.Sent > 0 {
+			color = heatColor(c.Accuracy())
+			tip = fmt.Sprintf("%c: %.1f%% of %d copied", r, c.Accuracy() * 100, c.Sent)
+		}
+		fmt.Fprintf(&sb, `<g><title>%s</title><rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="#fff"/>`, html.EscapeString(tip), cx, cy, heatCell, heatCell, color)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="middle" style="font-size: 16px">%s</text></g>`, cx + heatCell / 2, cy + heatCell / 2 + 6, html.EscapeString(string(r)))
+	}
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+// heatColor goes from red at 50% or worse, through yellow, to green at 100%.
+func heatColor(acc float64) string {
+	f := max(0, min(1, (acc - 0.5) / 0.5))
+	return fmt.Sprintf("hsl(%.0f, 70%%, 55%%)", f * 120)
+}
+
+// calendars draws a calendar for each year with sessions in it, with each day
+// shaded by how many lines were practiced that day.
+func (r *Report) calendars() []template.HTML {
+	lines := make(map[string]int)
+	sessions := make(map[string]int)
+	most := 0
+	for _, s := range r.Sessions {
+		d := s.Date.Format(dateFmt)
+		lines[d] += s.Count
+		sessions[d]++
+		most = max(most, lines[d])
+	}
+
+	cals := make([]template.HTML, 0)
+	for year := r.Sessions[0].Date.Year(); year <= r.Sessions[len(r.Sessions)-1].Date.Year(); year++ {
+		loc := r.Sessions[0].Date.Location()
+		jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+		offset := int(jan1.Weekday())
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, `<h3>%d</h3><svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`, year, calCell * 54, calCell * 7)
+		for d := jan1; d.Year() == year; d = d.AddDate(0, 0, 1) {
+			day := d.YearDay() - 1 + offset
+			key := d.Format(dateFmt)
+			level := 0
+			if lines[key] > 0 {
+				level = 1 + (lines[key] - 1) * (len(calColors) - 1) / max(1, most)
+				level = min(level, len(calColors) - 1)
+			}
+			fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="#fff"><title>%s: %d sessions, %d lines</title></rect>`, (day / 7) * calCell, (day % 7) * calCell, calCell, calCell, calColors[level], key, sessions[key], lines[key])
+		}
+		sb.WriteString("</svg>")
+		cals = append(cals, template.HTML(sb.String()))
+	}
+	return cals
+}
@@ -0,0 +1,252 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package report works out how a user's been doing over time from their
+// statistics: grouping sessions, averaging, spotting trends, personal bests,
+// and regressions.
+package report
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/stats"
+	"sort"
+)
+
+const (
+	// DefaultWindow is how many sessions go into a moving average.
+	DefaultWindow = 5
+	// GoodAccuracy is the accuracy a session needs for its speed to count
+	// towards the fastest speed personal best.
+	GoodAccuracy = 0.9
+	// RegressionDrop is how far below the moving average of the sessions
+	// before it a session's accuracy needs to fall to count as a
+	// regression.
+	RegressionDrop = 0.1
+)
+
+// Group is a set of sessions in the same mode at the same speed, which are the
+// ones that make sense to compare with each other.
+type Group struct {
+	Mode morse.MorseMode
+	Wpm int
+	Summaries []stats.Summary
+}
+
+// Best is a personal best, and the session it happened in.
+type Best struct {
+	Description string
+	Summary stats.Summary
+}
+
+// Regression is a session that went noticeably worse than the ones before it
+// in the same group.
+type Regression struct {
+	Group *Group
+	Summary stats.Summary
+	Previous float64
+}
+
+// Report is the analysis of a user's statistics.
+type Report struct {
+	Username string
+	Window int
+	Sessions []stats.Summary
+	Groups []*Group
+	Bests []Best
+	Regressions []Regression
+}
+
+// New analyzes the user's statistics. The window is how many sessions to use
+// for moving averages and for comparing against when looking for regressions.
+func New(u *stats.UserStats, window int) *Report {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	r := &Report{Username: u.Username, Window: window}
+
+	r.Sessions = make([]stats.Summary, len(u.Summaries))
+	copy(r.Sessions, u.Summaries)
+	sort.SliceStable(r.Sessions, func(i, j int) bool {
+		return r.Sessions[i].Date.Before(r.Sessions[j].Date)
+	})
+
+	r.Groups = GroupSummaries(r.Sessions)
+	r.Bests = findBests(r.Sessions, r.Groups)
+	r.Regressions = findRegressions(r.Groups, window)
+
+	return r
+}
+
+// Lines returns the total number of lines sent over all the sessions.
+func (r *Report) Lines() int {
+	return countLines(r.Sessions)
+}
+
+// Accuracy returns the average accuracy of each session, in order.
+func (r *Report) Accuracy() []float64 {
+	return accuracy(r.Sessions)
+}
+
+// Speeds returns the speed of each session, in order.
+func (r *Report) Speeds() []float64 {
+	sp := make([]float64, len(r.Sessions))
+	for i, s := range r.Sessions {
+		sp[i] = float64(s.Wpm)
+	}
+	return sp
+}
+
+// GroupSummaries sorts the summaries into groups by mode and speed. The
+// summaries in each group keep the order they came in.
+func GroupSummaries(sums []stats.Summary) []*Group {
+	type groupKey struct {
+		mode morse.MorseMode
+		wpm int
+	}
+	gm := make(map[groupKey]*Group)
+	groups := make([]*Group, 0)
+
+	for _, s := range sums {
+		k := groupKey{s.Mode, s.Wpm}
+		g, ok := gm[k]
+		if !ok {
+			g = &Group{Mode: s.Mode, Wpm: s.Wpm}
+			gm[k] = g
+			groups = append(groups, g)
+		}
+		g.Summaries = append(g.Summaries, s)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Mode != groups[j].Mode {
+			return groups[i].Mode < groups[j].Mode
+		}
+		return groups[i].Wpm < groups[j].Wpm
+	})
+
+	return groups
+}
+
+// Accuracy returns the average accuracy of each session in the group.
+func (g *Group) Accuracy() []float64 {
+	return accuracy(g.Summaries)
+}
+
+// Lines returns the total number of lines sent in the group's sessions.
+func (g *Group) Lines() int {
+	return countLines(g.Summaries)
+}
+
+// Best returns the group's session with the best accuracy.
+func (g *Group) Best() stats.Summary {
+	best := g.Summaries[0]
+	for _, s := range g.Summaries[1:] {
+		if s.AvgPerc > best.AvgPerc {
+			best = s
+		}
+	}
+	return best
+}
+
+// MovingAverage returns the average of each value and up to window-1 values
+// before it.
+func MovingAverage(vals []float64, window int) []float64 {
+	ma := make([]float64, len(vals))
+	var total float64
+	for i, v := range vals {
+		total += v
+		if i >= window {
+			total -= vals[i - window]
+		}
+		ma[i] = total / float64(min(i + 1, window))
+	}
+	return ma
+}
+
+// Trend returns the slope of the least squares line through the values, which
+// is how much they go up (or down) from one to the next on average.
+func Trend(vals []float64) float64 {
+	n := float64(len(vals))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, v := range vals {
+		x := float64(i)
+		sumX += x
+		sumY += v
+		sumXY += x * v
+		sumXX += x * x
+	}
+	return (n * sumXY - sumX * sumY) / (n * sumXX - sumX * sumX)
+}
+
+func findBests(sessions []stats.Summary, groups []*Group) []Best {
+	bests := make([]Best, 0)
+
+	var fastest *stats.Summary
+	for i, s := range sessions {
+		if s.AvgPerc >= GoodAccuracy && (fastest == nil || s.Wpm > fastest.Wpm) {
+			fastest = &sessions[i]
+		}
+	}
+	if fastest != nil {
+		bests = append(bests, Best{Description: "Fastest speed at 90% or better", Summary: *fastest})
+	}
+
+	for _, g := range groups {
+		bests = append(bests, Best{Description: fmt.Sprintf("Best accuracy in %s at %d wpm", g.Mode, g.Wpm), Summary: g.Best()})
+	}
+
+	return bests
+}
+
+func findRegressions(groups []*Group, window int) []Regression {
+	regs := make([]Regression, 0)
+
+	for _, g := range groups {
+		acc := g.Accuracy()
+		ma := MovingAverage(acc, window)
+		for i := 1; i < len(acc); i++ {
+			if ma[i-1] - acc[i] >= RegressionDrop {
+				regs = append(regs, Regression{Group: g, Summary: g.Summaries[i], Previous: ma[i-1]})
+			}
+		}
+	}
+
+	sort.SliceStable(regs, func(i, j int) bool {
+		return regs[i].Summary.Date.Before(regs[j].Summary.Date)
+	})
+	return regs
+}
+
+func accuracy(sums []stats.Summary) []float64 {
+	acc := make([]float64, len(sums))
+	for i, s := range sums {
+		acc[i] = s.AvgPerc
+	}
+	return acc
+}
+
+func countLines(sums []stats.Summary) int {
+	n := 0
+	for _, s := range sums {
+		n += s.Count
+	}
+	return n
+}
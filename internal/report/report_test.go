@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"bytes"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/stats"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+var ep float64 = 0.000001
+
+func testStats() *stats.UserStats {
+	u := stats.New()
+	u.Username = "dawg"
+	start := time.Date(2025, 3, 1, 19, 0, 0, 0, time.UTC)
+	sessions := []struct {
+		mode morse.MorseMode
+		wpm int
+		perc float64
+	}{
+		{morse.TopWords, 10, 0.80},
+		{morse.TopWords, 10, 0.85},
+		{morse.Qcode, 12, 0.95},
+		{morse.TopWords, 10, 0.90},
+		{morse.TopWords, 15, 0.70},
+		{morse.TopWords, 10, 0.60},
+		{morse.Qcode, 12, 0.92},
+	}
+	// add them out of order, to make sure they get sorted
+	for i := len(sessions) - 1; i >= 0; i-- {
+		s := sessions[i]
+		u.Add(stats.NewSummary(start.Add(time.Duration(i) * time.Hour * 24), s.mode, s.perc, time.Second, 1, 10, s.wpm, 0))
+	}
+	return u
+}
+
+func TestGroups(t *testing.T) {
+	r := New(testStats(), 3)
+	if len(r.Groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(r.Groups))
+	}
+	g := r.Groups[0]
+	if g.Mode != morse.TopWords || g.Wpm != 10 || len(g.Summaries) != 4 {
+		t.Errorf("first group should have been 4 TopWords sessions at 10 wpm, got %d %s sessions at %d", len(g.Summaries), g.Mode, g.Wpm)
+	}
+	if g.Lines() != 40 {
+		t.Errorf("first group should have had 40 lines, got %d", g.Lines())
+	}
+	if g.Best().AvgPerc != 0.90 {
+		t.Errorf("best TopWords session at 10 wpm should have been 90%%, got %f", g.Best().AvgPerc)
+	}
+	if r.Groups[1].Wpm != 15 || r.Groups[2].Mode != morse.Qcode {
+		t.Errorf("groups weren't sorted by mode and speed")
+	}
+	if !r.Sessions[0].Date.Before(r.Sessions[1].Date) {
+		t.Errorf("sessions weren't sorted by date")
+	}
+}
+
+func TestBestsAndRegressions(t *testing.T) {
+	r := New(testStats(), 3)
+	if len(r.Bests) != 4 {
+		t.Fatalf("expected 4 personal bests, got %d", len(r.Bests))
+	}
+	if r.Bests[0].Summary.Wpm != 12 {
+		t.Errorf("fastest speed at 90%% or better should have been 12 wpm, got %d", r.Bests[0].Summary.Wpm)
+	}
+
+	if len(r.Regressions) != 1 {
+		t.Fatalf("expected one regression, got %d", len(r.Regressions))
+	}
+	reg := r.Regressions[0]
+	if reg.Summary.AvgPerc != 0.60 || !floatEq(reg.Previous, 0.85) {
+		t.Errorf("regression should have been 60%% after an average of 85%%, got %f after %f", reg.Summary.AvgPerc, reg.Previous)
+	}
+}
+
+func TestMovingAverageAndTrend(t *testing.T) {
+	ma := MovingAverage([]float64{1, 2, 3, 4, 5}, 2)
+	exp := []float64{1, 1.5, 2.5, 3.5, 4.5}
+	for i := range exp {
+		if !floatEq(ma[i], exp[i]) {
+			t.Errorf("moving average %d should have been %f, got %f", i, exp[i], ma[i])
+		}
+	}
+
+	if tr := Trend([]float64{1, 3, 5, 7}); !floatEq(tr, 2) {
+		t.Errorf("trend should have been 2, got %f", tr)
+	}
+	if tr := Trend([]float64{5, 4, 3}); !floatEq(tr, -1) {
+		t.Errorf("trend should have been -1, got %f", tr)
+	}
+	if tr := Trend([]float64{5}); tr != 0 {
+		t.Errorf("trend of one value should have been 0, got %f", tr)
+	}
+}
+
+func TestSparklineAndChart(t *testing.T) {
+	if s := Sparkline([]float64{0, 0.5, 1}, 0, 1); s != "▁▅█" {
+		t.Errorf("unexpected sparkline '%s'", s)
+	}
+	rows := Chart([]float64{0, 1}, nil, 0, 1, 3)
+	if len(rows) != 3 || rows[0] != " *" || rows[2] != "* " {
+		t.Errorf("unexpected chart %q", rows)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := New(testStats(), 3).WriteText(buf); err != nil {
+		t.Fatalf("error writing report: %v", err)
+	}
+	out := buf.String()
+	for _, exp := range []string{"7 sessions and 70 lines", "Personal bests", "Recent regressions", "TopWords at 10 wpm"} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("report should have included '%s':\n%s", exp, out)
+		}
+	}
+
+	buf.Reset()
+	if err := New(stats.New(), 0).WriteText(buf); err != nil || !strings.Contains(buf.String(), "No sessions") {
+		t.Errorf("report with no sessions should say so, got '%s' (%v)", buf.String(), err)
+	}
+}
+
+func TestCharStats(t *testing.T) {
+	s := stats.NewSummary(time.Now(), morse.TopWords, 0.5, time.Second, 1, 2, 10, 0)
+	s.Answers = []stats.Answer{
+		{Original: "the cat", Response: "the bat"},
+		{Original: "TEA", Response: "tea"},
+	}
+	cs := CharStats([]stats.Summary{s})
+	if _, ok := cs[' ']; ok {
+		t.Errorf("spaces shouldn't be counted")
+	}
+	if c := cs['t']; c == nil || c.Sent != 3 || c.Correct != 3 {
+		t.Errorf("'t' should have been sent 3 times and copied 3 times, got %+v", c)
+	}
+	if c := cs['c']; c == nil || c.Sent != 1 || c.Correct != 0 || c.Accuracy() != 0 {
+		t.Errorf("'c' should have been sent once and never copied, got %+v", c)
+	}
+}
+
+func TestWriteCharErrors(t *testing.T) {
+	buf := new(bytes.Buffer)
+	rates := []stats.CharErrorRate{{Char: 'q', Sent: 4, Missed: 2}, {Char: 'e', Sent: 10, Missed: 0}}
+	if err := WriteCharErrors(buf, rates, 30); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, exp := range []string{"last 30 days", "q     4     2       50.0%       ##########", "e     10    0       0.0%"} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("character error report should have included '%s', got:\n%s", exp, out)
+		}
+	}
+
+	buf.Reset()
+	WriteCharErrors(buf, nil, 7)
+	if !strings.Contains(buf.String(), "No answers saved in the last 7 days") {
+		t.Errorf("an empty character error report should say so, got '%s'", buf.String())
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	u := testStats()
+	u.Summaries[0].Answers = []stats.Answer{{Original: "qrm", Response: "qrn"}}
+	buf := new(bytes.Buffer)
+	if err := New(u, 3).WriteHTML(buf); err != nil {
+		t.Fatalf("error writing HTML report: %v", err)
+	}
+	out := buf.String()
+	for _, exp := range []string{"<svg", "<polyline", "Progress report for dawg", "TopWords", "m: 0.0% of 1 copied", "<h3>2025</h3>"} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("HTML report should have included '%s'", exp)
+		}
+	}
+	if strings.Contains(out, "<script") || strings.Contains(out, "http://") && !strings.Contains(out, "http://www.w3.org/2000/svg") {
+		t.Errorf("HTML report shouldn't depend on anything outside the file")
+	}
+
+	buf.Reset()
+	if err := New(stats.New(), 0).WriteHTML(buf); err != nil || !strings.Contains(buf.String(), "No sessions saved yet") {
+		t.Errorf("HTML report with no sessions should say so (%v)", err)
+	}
+}
+
+func floatEq(a float64, b float64) bool {
+	return math.Abs(a - b) <= ep
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/stats"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// Terminal output for reports: sparklines, a simple chart, and some tables.
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+const (
+	chartHeight = 10
+	// MaxChartWidth is how many of the most recent sessions fit in charts
+	// and sparklines.
+	MaxChartWidth = 60
+	dateFmt = "2006-01-02"
+	maxRegressions = 10
+)
+
+// Sparkline draws the values as a line of little bars, scaled so that lo is
+// the shortest bar and hi the tallest.
+func Sparkline(vals []float64, lo float64, hi float64) string {
+	var sb strings.Builder
+	for _, v := range vals {
+		sb.WriteRune(sparks[scale(v, lo, hi, len(sparks))])
+	}
+	return sb.String()
+}
+
+// Chart draws the values as rows of text, top row first, with a '*' where each
+// value falls. If there are averages, they're drawn as '-' underneath the
+// values.
+func Chart(vals []float64, avgs []float64, lo float64, hi float64, height int) []string {
+	grid := make([][]rune, height)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", len(vals)))
+	}
+
+	for x := range vals {
+		if x < len(avgs) {
+			grid[height - 1 - scale(avgs[x], lo, hi, height)][x] = '-'
+		}
+		grid[height - 1 - scale(vals[x], lo, hi, height)][x] = '*'
+	}
+
+	rows := make([]string, height)
+	for i, r := range grid {
+		rows[i] = string(r)
+	}
+	return rows
+}
+
+// scale figures out which of n steps between lo and hi the value falls in.
+func scale(v float64, lo float64, hi float64, n int) int {
+	if hi <= lo {
+		return n / 2
+	}
+	s := int(math.Round((v - lo) / (hi - lo) * float64(n - 1)))
+	return max(0, min(n - 1, s))
+}
+
+// WriteText writes the report out in a form that's meant to be read in a
+// terminal.
+func (r *Report) WriteText(w io.Writer) error {
+	if len(r.Sessions) == 0 {
+		_, err := fmt.Fprintf(w, "No sessions saved for '%s' yet.\n", r.Username)
+		return err
+	}
+
+	first, last := r.Sessions[0], r.Sessions[len(r.Sessions)-1]
+	fmt.Fprintf(w, "Progress report for '%s': %d sessions and %d lines from %s to %s.\n\n", r.Username, len(r.Sessions), r.Lines(), first.Date.Format(dateFmt), last.Date.Format(dateFmt))
+
+	acc := lastN(r.Accuracy(), MaxChartWidth)
+	ma := lastN(MovingAverage(r.Accuracy(), r.Window), MaxChartWidth)
+	fmt.Fprintf(w, "Accuracy over the last %d sessions ('*' each session, '-' the average of %d):\n", len(acc), r.Window)
+	for i, row := range Chart(acc, ma, 0, 1, chartHeight) {
+		label := "    "
+		switch i {
+		case 0:
+			label = "100%"
+		case chartHeight / 2:
+			label = " 50%"
+		case chartHeight - 1:
+			label = "  0%"
+		}
+		fmt.Fprintf(w, "%s |%s\n", label, row)
+	}
+	fmt.Fprintf(w, "     +%s\n", strings.Repeat("-", len(acc)))
+
+	speeds := lastN(r.Speeds(), MaxChartWidth)
+	lo, hi := minMax(speeds)
+	fmt.Fprintf(w, "Speed: %s %.0f-%.0f wpm, trending %+.2f wpm per session.\n\n", Sparkline(speeds, lo, hi), lo, hi, Trend(r.Speeds()))
+
+	fmt.Fprintln(w, "By mode and speed:")
+	tw := new(tabwriter.Writer)
+	tw.Init(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Mode\tWPM\tSessions\tLines\tLast\tAvg of %d\tBest\tTrend\tAccuracy\n", r.Window)
+	for _, g := range r.Groups {
+		gacc := g.Accuracy()
+		gma := MovingAverage(gacc, r.Window)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f%%\t%.1f%%\t%.1f%%\t%+.1f\t%s\n", g.Mode, g.Wpm, len(g.Summaries), g.Lines(), gacc[len(gacc)-1] * 100, gma[len(gma)-1] * 100, g.Best().AvgPerc * 100, Trend(gacc) * 100, Sparkline(lastN(gacc, MaxChartWidth), 0, 1))
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "(Trend is the change in accuracy per session, in percentage points.)")
+
+	fmt.Fprintln(w, "\nPersonal bests:")
+	for _, b := range r.Bests {
+		fmt.Fprintf(w, "- %s: %d wpm at %.1f%% on %s.\n", b.Description, b.Summary.Wpm, b.Summary.AvgPerc * 100, b.Summary.Date.Format(dateFmt))
+	}
+
+	if len(r.Regressions) > 0 {
+		fmt.Fprintln(w, "\nRecent regressions:")
+		regs := r.Regressions
+		if len(regs) > maxRegressions {
+			regs = regs[len(regs) - maxRegressions:]
+		}
+		for _, reg := range regs {
+			fmt.Fprintf(w, "- %s at %d wpm on %s: %.1f%%, down %.1f points from the average of %.1f%% before it.\n", reg.Group.Mode, reg.Group.Wpm, reg.Summary.Date.Format(dateFmt), reg.Summary.AvgPerc * 100, (reg.Previous - reg.Summary.AvgPerc) * 100, reg.Previous * 100)
+		}
+	}
+
+	return nil
+}
+
+// WriteCharErrors writes out how often each character was missed, worst first.
+func WriteCharErrors(w io.Writer, rates []stats.CharErrorRate, days int) error {
+	if len(rates) == 0 {
+		_, err := fmt.Fprintf(w, "No answers saved in the last %d days.\n", days)
+		return err
+	}
+
+	fmt.Fprintf(w, "Character error rates over the last %d days, worst first:\n", days)
+	tw := new(tabwriter.Writer)
+	tw.Init(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "Char\tSent\tMissed\tError rate\t")
+	for _, c := range rates {
+		fmt.Fprintf(tw, "%c\t%d\t%d\t%.1f%%\t%s\n", c.Char, c.Sent, c.Missed, c.Rate() * 100, strings.Repeat("#", int(math.Round(c.Rate() * 20))))
+	}
+	return tw.Flush()
+}
+
+func lastN(vals []float64, n int) []float64 {
+	if len(vals) > n {
+		return vals[len(vals) - n:]
+	}
+	return vals
+}
+
+func minMax(vals []float64) (float64, float64) {
+	if len(vals) == 0 {
+		return 0, 0
+	}
+	lo, hi := vals[0], vals[0]
+	for _, v := range vals[1:] {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	return lo, hi
+}
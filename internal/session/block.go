@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package session
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RunBlock sends the whole block of text (or lines first through last of the
+// file, where 0 means the start or end) at once, then takes the entire transcription and scores it line by line, the
+// way a copy exam would. The session's saved if anything was copied.
+func (e *Engine) RunBlock(first int, last int) (*Result, error) {
+	if e.Lines != 0 || e.Duration != 0 {
+		return nil, fmt.Errorf("limits on lines or time don't work when sending the entire block at once")
+	}
+	e.started = time.Now()
+
+	start, end, err := e.Morse.BlockRange(first, last)
+	if err != nil {
+		return nil, err
+	}
+	paras, err := e.Morse.GetBlock(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	orig := make([]string, 0)
+	for _, p := range paras {
+		for _, ml := range p {
+			orig = append(orig, ml.RawString())
+		}
+	}
+
+	input := e.Input.Answers()
+	var copied []string
+	var began time.Time
+	tries := 0
+	for len(copied) == 0 {
+		tries++
+		e.Output.Say(fmt.Sprintf("# Sending %d lines in %d paragraphs", len(orig), len(paras)))
+		if err = e.Morse.SendBlock(paras); err != nil {
+			return nil, err
+		}
+		select {
+		case <-e.interrupted:
+			return e.nothingCopied(Interrupted), nil
+		default:
+		}
+		began = time.Now()
+		e.Output.Say("Enter your copy, and finish with a blank line. A blank line by itself will send the block again.")
+
+	Copy:
+		for {
+			e.Output.Prompt()
+			select {
+			case ln, ok := <-input:
+				if !ok {
+					// nothing more is coming, so don't
+					// keep resending the block forever.
+					if len(copied) == 0 {
+						return e.nothingCopied(NoMoreInput), nil
+					}
+					break Copy
+				}
+				ln = strings.ToLower(strings.TrimSpace(ln))
+				if ln == "" {
+					break Copy
+				}
+				copied = append(copied, ln)
+			case <-e.interrupted:
+				// score whatever's been copied so far
+				if len(copied) == 0 {
+					return e.nothingCopied(Interrupted), nil
+				}
+				break Copy
+			}
+		}
+	}
+
+	answers := e.Comparator.CompareBlock(orig, strings.Join(copied, " "), began, tries)
+	for i := range answers {
+		answers[i] = e.answered(answers[i], false)
+	}
+	var sb strings.Builder
+	for i, ans := range e.answers {
+		fmt.Fprintf(&sb, "Line %d was %.2f%% correct. Copied: '%s' Original: '%s'\n", e.Morse.SourceLine(i + start), ans.Percentage * 100, ans.Response, ans.Original)
+	}
+	perc, _, _ := e.answers.Averages()
+	fmt.Fprintf(&sb, "Overall: %.2f%% correct. Took %d tries over %s.", perc * 100, tries, time.Since(began).Round(time.Second / 100))
+	e.Output.Say(sb.String())
+
+	return e.end(BlockDone)
+}
+
+// nothingCopied ends a block session before anything was copied, without
+// saving it.
+func (e *Engine) nothingCopied(reason EndReason) *Result {
+	e.Output.Say(fmt.Sprintf("%s Nothing was copied, so there's nothing to save.", reason))
+	res := &Result{Reason: reason}
+	if e.Hooks.Ended != nil {
+		e.Hooks.Ended(res)
+	}
+	return res
+}
@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package session
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+)
+
+// how much `slower and `faster change the speed by, and how slow is too slow.
+const (
+	speedStep = 2
+	minWPM = 2
+)
+
+// command handles the commands that can be entered instead of an answer.
+func (e *Engine) command(guess string, ln *line) (action, EndReason) {
+	cmd := strings.Fields(guess)
+	switch cmd[0] {
+	case "`quit", "`exit":
+		return end, Quit
+	case "`replay":
+		return resend, 0
+	case "`skip":
+		ans := e.Comparator.Compare(ln.ml.RawString(), "", ln.start, ln.tries)
+		ans.Skipped = true
+		e.answered(ans, ln.hinted)
+		e.Output.Say(fmt.Sprintf("Skipped. The line was '%s'.", ln.ml.RawString()))
+		if e.linesDone() {
+			return end, LinesDone
+		}
+		return nextLine, 0
+	case "`hint":
+		ln.hinted = true
+		e.Output.Say(fmt.Sprintf("The first word is '%s'.", ln.ml[0]))
+		return prompt, 0
+	case "`help":
+		e.Output.Say(CommandHelp())
+		return prompt, 0
+	case "`wpm", "`farns", "`freq":
+		if len(cmd) != 2 {
+			e.Output.Say(fmt.Sprintf("'%s' needs a number, like '%s 15'.", cmd[0], cmd[0]))
+			return prompt, 0
+		}
+		n, err := strconv.Atoi(cmd[1])
+		if err == nil {
+			switch cmd[0] {
+			case "`wpm":
+				err = e.Morse.SetSpeed(n, e.Morse.Farnsworth)
+			case "`farns":
+				err = e.Morse.SetSpeed(e.Morse.WPM, n)
+			case "`freq":
+				err = e.Morse.SetFrequency(float64(n))
+			}
+		}
+		if err != nil {
+			e.Output.Say(fmt.Sprintf("Couldn't change the setting to '%s': %s", cmd[1], err))
+			return prompt, 0
+		}
+		e.settingsChanged()
+	case "`slower", "`faster":
+		wpm := e.Morse.WPM + speedStep
+		if cmd[0] == "`slower" {
+			wpm = e.Morse.WPM - speedStep
+		}
+		if wpm < minWPM {
+			e.Output.Say(fmt.Sprintf("Can't go any slower than %d wpm.", minWPM))
+			return prompt, 0
+		}
+		if err := e.Morse.SetSpeed(wpm, e.Morse.Farnsworth); err != nil {
+			e.Output.Say(fmt.Sprintf("Couldn't change the speed: %s", err))
+			return prompt, 0
+		}
+		e.settingsChanged()
+	case "`stats":
+		e.Output.Say(e.statsText())
+	case "`remind":
+		e.Output.Say(remindText())
+	default:
+		e.Output.Say(fmt.Sprintf("Unknown command '%s'. Try `help for a list of commands.", guess))
+		return prompt, 0
+	}
+	return resend, 0
+}
+
+func (e *Engine) settingsChanged() {
+	m := e.Morse
+	s := fmt.Sprintf("Now sending at %d wpm", m.WPM)
+	if m.Farnsworth != 0 {
+		s += fmt.Sprintf(" with Farnsworth spacing at %d wpm", m.Farnsworth)
+	}
+	s += fmt.Sprintf(", at %.0f Hz.", m.Frequency)
+	e.Output.Say(s)
+
+	if e.Hooks.SettingsChanged != nil {
+		e.Hooks.SettingsChanged(m.WPM, m.Farnsworth, m.Frequency)
+	}
+}
+
+func (e *Engine) statsText() string {
+	if e.Stats == nil {
+		return "No statistics are being kept."
+	}
+	e.lockStats()
+	defer e.unlockStats()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Statistics for '%s':\n\n", e.Stats.Username)
+	for _, st := range e.Stats.Summaries {
+		fmt.Fprintln(&sb, st)
+	}
+	e.Stats.WriteProgress(&sb, time.Now(), false)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// CommandHelp describes the commands that can be entered instead of an
+// answer.
+func CommandHelp() string {
+	var sb strings.Builder
+	tw := new(tabwriter.Writer)
+	tw.Init(&sb, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "`replay\tSend the current line again. An empty line does the same.")
+	fmt.Fprintln(tw, "`skip\tSkip the current line. It still counts in the statistics.")
+	fmt.Fprintln(tw, "`hint\tShow the first word of the current line.")
+	fmt.Fprintln(tw, "`wpm N\tChange the speed to N words per minute.")
+	fmt.Fprintln(tw, "`farns N\tChange the Farnsworth spacing to N wpm. 0 turns it off.")
+	fmt.Fprintln(tw, "`freq N\tChange the beep frequency to N Hz.")
+	fmt.Fprintf(tw, "`slower, `faster\tChange the speed by %d wpm.\n", speedStep)
+	fmt.Fprintln(tw, "`stats\tPrint your statistics.")
+	fmt.Fprintln(tw, "`remind\tPrint a table of the Morse characters.")
+	fmt.Fprintln(tw, "`help\tPrint this help.")
+	fmt.Fprintln(tw, "`quit, `exit\tSave your statistics and exit.")
+	tw.Flush()
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// remindText makes a table of the Morse characters, letters first, then
+// numbers, then everything else.
+func remindText() string {
+	// sort round
+	i := 0
+	k := make([]rune, len(morsestrings.Alphabet))
+	for r := range morsestrings.Alphabet {
+		k[i] = r
+		i++
+	}
+
+	// This seems like it should be able to do in a cleaner fashion, but
+	// I'm spacing on how exactly.
+	runeSort := func (i, j int) bool {
+		if unicode.IsLetter(k[i]) {
+			if unicode.IsNumber(k[j]) || unicode.IsPunct(k[j]) || unicode.IsSymbol(k[j]) {
+				return true
+			}
+		} else if unicode.IsNumber(k[i]) {
+			if unicode.IsLetter(k[j]) {
+				return false
+			} else if unicode.IsPunct(k[j]) || unicode.IsSymbol(k[j]) {
+				return true
+			}
+		} else if unicode.IsPunct(k[i]) || unicode.IsSymbol(k[i]) {
+			if unicode.IsLetter(k[j]) || unicode.IsNumber(k[j]) {
+				return false
+			}
+		}
+
+		// fallthrough, they're the same class of character
+		return k[i] < k[j]
+	}
+	sort.Slice(k, runeSort)
+
+	var sb strings.Builder
+	tw := new(tabwriter.Writer)
+	tw.Init(&sb, 9, 8, 2, ' ', 0)
+	i = 1
+	for _, r := range k {
+		if r == '0' || r == '"' {
+			i = 1
+			fmt.Fprint(tw, "\n\n")
+		}
+		fmt.Fprintf(tw, "%c| %s\t", r, morsestrings.Alphabet[r])
+		if i % 5 == 0 {
+			fmt.Fprintln(tw)
+		}
+		i++
+	}
+	fmt.Fprintln(tw)
+	tw.Flush()
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/stats"
+	"io"
+	"sync"
+	"time"
+)
+
+// Input is where a session's answers come from.
+type Input interface {
+	// Answers returns a channel with each line that's entered, which is
+	// closed when there's no more input.
+	Answers() <-chan string
+}
+
+// Output is where everything the person practicing needs to see goes.
+type Output interface {
+	// Line is called before each line is sent.
+	Line(st Status)
+	// Prompt is called whenever an answer's wanted.
+	Prompt()
+	// Scored is called with each answer once it's been scored.
+	Scored(ans compare.Answer)
+	// Say is for everything else, like hints and what commands print. It
+	// may be more than one line long.
+	Say(text string)
+	// Ended is called when the session's over, before it's saved.
+	Ended(reason EndReason)
+	// Saved is called with the session's summary once it's been saved.
+	Saved(sum stats.Summary)
+}
+
+// Status is where a session's at when a line's about to be sent.
+type Status struct {
+	// Num is the number of the line, starting at 1.
+	Num int
+	// Of is how many lines the session's limited to, if it is.
+	Of int
+	// Timed is set if the session has a time limit, and Left is how long
+	// is left of it.
+	Timed bool
+	Left time.Duration
+}
+
+// String numbers the line, along with how many lines or how much time is left
+// if the session's limited.
+func (st Status) String() string {
+	h := fmt.Sprintf("# %d", st.Num)
+	if st.Of > 0 {
+		h += fmt.Sprintf(" of %d", st.Of)
+	}
+	if st.Timed {
+		h += fmt.Sprintf(" (%s left)", st.Left.Round(time.Second))
+	}
+	return h
+}
+
+// ReaderInput reads answers a line at a time from an io.Reader, like standard
+// input. The reading's done in the background, so that waiting for an answer
+// can be cut short when time's up.
+type ReaderInput struct {
+	r *bufio.Reader
+	once sync.Once
+	answers chan string
+}
+
+func NewReaderInput(r io.Reader) *ReaderInput {
+	return &ReaderInput{r: bufio.NewReader(r)}
+}
+
+func (ri *ReaderInput) Answers() <-chan string {
+	ri.once.Do(func() {
+		ri.answers = make(chan string)
+		go func() {
+			defer close(ri.answers)
+			for {
+				line, err := ri.r.ReadString('\n')
+				if line != "" {
+					ri.answers <- line
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	})
+	return ri.answers
+}
+
+// TextOutput writes a session out as plain text, the way it looks in a
+// terminal.
+type TextOutput struct {
+	w io.Writer
+	prompted bool
+}
+
+func NewTextOutput(w io.Writer) *TextOutput {
+	return &TextOutput{w: w}
+}
+
+func (to *TextOutput) Line(st Status) {
+	to.prompted = false
+	fmt.Fprintln(to.w, st)
+}
+
+func (to *TextOutput) Prompt() {
+	to.prompted = true
+	fmt.Fprint(to.w, "> ")
+}
+
+func (to *TextOutput) Scored(ans compare.Answer) {
+	to.prompted = false
+	fmt.Fprintf(to.w, "'%s' was %.2f%% correct. Took %d tries over %s. Original: '%s'\n", ans.Response, ans.Percentage * 100, ans.Tries, ans.Took.Round(time.Second / 100), ans.Original)
+}
+
+func (to *TextOutput) Say(text string) {
+	to.prompted = false
+	fmt.Fprintln(to.w, text)
+}
+
+func (to *TextOutput) Ended(reason EndReason) {
+	// if the session ended while waiting for an answer, the prompt's
+	// still sitting there without a newline
+	if to.prompted && reason != Quit {
+		fmt.Fprintln(to.w)
+	}
+	to.prompted = false
+	fmt.Fprintln(to.w, reason)
+	fmt.Fprintln(to.w, "Saving and exiting...")
+}
+
+func (to *TextOutput) Saved(sum stats.Summary) {
+	fmt.Fprintln(to.w, sum)
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package session
+
+import (
+	"encoding/json"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/stats"
+	"io"
+)
+
+// JSONOutput writes a session out as JSON, one record per line, for other
+// programs to read. Each record has a "type" saying what it is:
+//
+//	line     a line's about to be sent
+//	answer   an answer's been scored, or the line was skipped
+//	message  anything else the session has to say
+//	end      the session's over, and why
+//	saved    the session was saved to the statistics
+//	result   the averages for the whole session
+//
+// Answers leave out when they were given and how long they took, so the same
+// script with the same seed gives the same output every time. The answer and
+// result records come from hooks, so skipped lines get written out too; set
+// the session's Hooks to the ones from Hooks.
+type JSONOutput struct {
+	enc *json.Encoder
+	lines int
+}
+
+type jsonRecord struct {
+	Type string `json:"type"`
+	Line int `json:"line,omitempty"`
+	Of int `json:"of,omitempty"`
+	Original string `json:"original,omitempty"`
+	Response *string `json:"response,omitempty"`
+	Percentage *float64 `json:"percentage,omitempty"`
+	Tries int `json:"tries,omitempty"`
+	Wpm int `json:"wpm,omitempty"`
+	Farnsworth int `json:"farnsworth,omitempty"`
+	Frequency float64 `json:"frequency,omitempty"`
+	Hinted bool `json:"hinted,omitempty"`
+	Skipped bool `json:"skipped,omitempty"`
+	Text string `json:"text,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Count *int `json:"count,omitempty"`
+	AvgTries *float64 `json:"avg_tries,omitempty"`
+}
+
+func NewJSONOutput(w io.Writer) *JSONOutput {
+	return &JSONOutput{enc: json.NewEncoder(w)}
+}
+
+// Hooks returns the hooks that write the answer and result records.
+func (jo *JSONOutput) Hooks() Hooks {
+	return Hooks{Answered: jo.answered, Ended: jo.result}
+}
+
+func (jo *JSONOutput) write(rec jsonRecord) {
+	// nothing much to be done if this fails, and the session shouldn't
+	// stop over it
+	jo.enc.Encode(rec)
+}
+
+func (jo *JSONOutput) Line(st Status) {
+	jo.lines = st.Num
+	jo.write(jsonRecord{Type: "line", Line: st.Num, Of: st.Of})
+}
+
+func (jo *JSONOutput) Prompt() {}
+
+// Scored doesn't write anything, since the Answered hook already has.
+func (jo *JSONOutput) Scored(ans compare.Answer) {}
+
+func (jo *JSONOutput) Say(text string) {
+	jo.write(jsonRecord{Type: "message", Text: text})
+}
+
+func (jo *JSONOutput) Ended(reason EndReason) {
+	jo.write(jsonRecord{Type: "end", Reason: reason.String()})
+}
+
+func (jo *JSONOutput) Saved(sum stats.Summary) {
+	jo.write(jsonRecord{Type: "saved", Count: &sum.Count})
+}
+
+func (jo *JSONOutput) answered(ans compare.Answer) {
+	jo.write(jsonRecord{Type: "answer", Line: jo.lines, Original: ans.Original, Response: &ans.Response, Percentage: &ans.Percentage, Tries: ans.Tries, Wpm: ans.Wpm, Farnsworth: ans.Farnsworth, Frequency: ans.Frequency, Hinted: ans.Hinted, Skipped: ans.Skipped})
+}
+
+func (jo *JSONOutput) result(res *Result) {
+	perc, _, tries := res.Answers.Averages()
+	count := len(res.Answers)
+	jo.write(jsonRecord{Type: "result", Count: &count, Percentage: &perc, AvgTries: &tries})
+}
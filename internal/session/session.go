@@ -0,0 +1,347 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package session runs practice sessions: choosing the lines to send, taking
+// answers and commands, scoring, adjusting the speed, and saving the results.
+// Where the answers come from and where everything's shown is up to the
+// Input and Output it's given, so the same session can be run in a terminal,
+// a browser, or a test.
+package session
+
+import (
+	"errors"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/morserrors"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"github.com/ctdk/morseudar/internal/stats"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndReason is why a session ended.
+type EndReason uint8
+
+const (
+	Quit EndReason = iota // the person practicing quit
+	LinesDone // the session's limit on lines was reached
+	TimeUp // the session's time limit was reached
+	Interrupted // Interrupt was called, say from a signal
+	NoMoreInput // the input ran out
+	EndOfText // there's nothing left to send
+	BlockDone // the copy of an entire block was scored
+)
+
+func (r EndReason) String() string {
+	switch r {
+	case Quit:
+		return "Quitting."
+	case LinesDone:
+		return "That's all the lines."
+	case TimeUp:
+		return "Time's up!"
+	case Interrupted:
+		return "Interrupted."
+	case NoMoreInput:
+		return "No more input."
+	case EndOfText:
+		return "That's the end of the text."
+	case BlockDone:
+		return "That's the whole block."
+	}
+	return "Done."
+}
+
+// Hooks are called as things happen in a session, for anything that wants to
+// keep track of them, like a log or a test. Any of them can be left nil.
+type Hooks struct {
+	// Sending is called right before a line is sent, with the line and
+	// how long sending it will take, for anything that needs to keep
+	// time with it or play it somewhere else. The line isn't for showing
+	// the person practicing, of course.
+	Sending func(st Status, ml morsestrings.MorseString, dur time.Duration)
+	// LineSent is called each time a line is sent, including when it's
+	// sent again.
+	LineSent func(st Status, line string)
+	// Answered is called with each answer, including skipped lines.
+	Answered func(ans compare.Answer)
+	// SettingsChanged is called when the speed or frequency changes,
+	// whether it's from a command or adaptive speed.
+	SettingsChanged func(wpm int, farnsworth int, freq float64)
+	// Ended is called last of all, once the session's been saved.
+	Ended func(res *Result)
+}
+
+// Result is how a session went.
+type Result struct {
+	Reason EndReason
+	Answers compare.AnswerBatch
+	// Summary is the summary that was saved, if the session was saved.
+	Summary *stats.Summary
+}
+
+// Engine runs a session. Morse, Comparator, Input, and Output need to be set;
+// the rest are optional.
+type Engine struct {
+	Morse *morse.Morse
+	Comparator *compare.Comparator
+	Input Input
+	Output Output
+	Hooks Hooks
+	// Stats, if set, is where the session's saved when it's over, and
+	// what the `stats command shows.
+	Stats *stats.UserStats
+	// StatsLock, if set, is held while using Stats, for when more than
+	// one session at a time shares them.
+	StatsLock sync.Locker
+	// Lines and Duration end the session after that many lines or that
+	// long, if they're set.
+	Lines int
+	Duration time.Duration
+
+	answers compare.AnswerBatch
+	started time.Time
+	interrupted chan struct{}
+	interruptOnce sync.Once
+}
+
+// what to do after handling an answer or command
+type action uint8
+
+const (
+	resend action = iota
+	prompt
+	nextLine
+	end
+)
+
+// the line being practiced
+type line struct {
+	ml morsestrings.MorseString
+	tries int
+	hinted bool
+	start time.Time
+}
+
+func New(m *morse.Morse, comp *compare.Comparator, in Input, out Output) *Engine {
+	return &Engine{Morse: m, Comparator: comp, Input: in, Output: out, interrupted: make(chan struct{})}
+}
+
+// Interrupt cuts off whatever's being sent and ends the session as soon as it
+// can, saving what's been done so far. It's safe to call from another
+// goroutine, like a signal handler, and more than once.
+//
+// Stopping the Morse being sent stops the speaker for good, so this is really
+// only for shutting down.
+func (e *Engine) Interrupt() {
+	e.interruptOnce.Do(func() {
+		close(e.interrupted)
+		e.Morse.Stop()
+	})
+}
+
+// Run sends lines one at a time and takes the answers to each until the
+// session ends, then saves it.
+func (e *Engine) Run() (*Result, error) {
+	if e.Lines < 0 || e.Duration < 0 {
+		return nil, errors.New("the number of lines and the duration can't be negative")
+	}
+	e.started = time.Now()
+
+	var timeUp <-chan time.Time
+	var deadline time.Time
+	if e.Duration > 0 {
+		timer := time.NewTimer(e.Duration)
+		defer timer.Stop()
+		timeUp = timer.C
+		deadline = e.started.Add(e.Duration)
+	}
+	input := e.Input.Answers()
+
+	for {
+		ml, err := e.Morse.GetMorse()
+		if errors.Is(err, morserrors.EOF) {
+			return e.end(EndOfText)
+		} else if err != nil {
+			return nil, err
+		}
+		ln := &line{ml: ml}
+		send := true
+
+		for {
+			if send {
+				st := Status{Num: len(e.answers) + 1, Of: e.Lines}
+				if !deadline.IsZero() {
+					st.Timed = true
+					st.Left = time.Until(deadline)
+				}
+				e.Output.Line(st)
+				if e.Hooks.Sending != nil {
+					dur, err := e.Morse.Duration(ml)
+					if err != nil {
+						return nil, err
+					}
+					e.Hooks.Sending(st, ml, dur)
+				}
+				if err = e.Morse.Send(ml); err != nil {
+					return nil, err
+				}
+				if e.Hooks.LineSent != nil {
+					e.Hooks.LineSent(st, ml.RawString())
+				}
+				ln.tries++
+				ln.start = time.Now()
+			}
+
+			// if sending the line was cut off by an interrupt,
+			// don't wait for an answer to it
+			select {
+			case <-e.interrupted:
+				return e.end(Interrupted)
+			default:
+			}
+
+			e.Output.Prompt()
+			var guess string
+			select {
+			case in, ok := <-input:
+				if !ok {
+					return e.end(NoMoreInput)
+				}
+				guess = in
+			case <-timeUp:
+				return e.end(TimeUp)
+			case <-e.interrupted:
+				return e.end(Interrupted)
+			}
+
+			act, reason := e.handle(guess, ln)
+			if act == end {
+				return e.end(reason)
+			} else if act == nextLine {
+				break
+			}
+			send = act == resend
+		}
+	}
+}
+
+// handle deals with whatever was entered for the current line.
+func (e *Engine) handle(guess string, ln *line) (action, EndReason) {
+	guess = strings.ToLower(strings.TrimSpace(guess))
+
+	if guess == "" {
+		e.Output.Say("?")
+		return resend, 0
+	}
+
+	// commands start with ` since that character's not going to come up
+	// as Morse code.
+	if strings.HasPrefix(guess, "`") {
+		return e.command(guess, ln)
+	}
+
+	ans := e.Comparator.Compare(ln.ml.RawString(), guess, ln.start, ln.tries)
+	e.Output.Scored(e.answered(ans, ln.hinted))
+	if e.linesDone() {
+		return end, LinesDone
+	}
+
+	if changed, err := e.Morse.Adapt(ans.Percentage); err != nil {
+		e.Output.Say("Couldn't adjust the speed: " + err.Error())
+	} else if changed {
+		e.settingsChanged()
+	}
+	return nextLine, 0
+}
+
+// answered notes the settings the line was sent with on the answer, so
+// changing them partway through a session doesn't muddy the statistics, and
+// adds it to the session.
+func (e *Engine) answered(ans compare.Answer, hinted bool) compare.Answer {
+	recordSettings(&ans, e.Morse, hinted)
+	e.answers = append(e.answers, ans)
+	if e.Hooks.Answered != nil {
+		e.Hooks.Answered(ans)
+	}
+	return ans
+}
+
+func recordSettings(ans *compare.Answer, m *morse.Morse, hinted bool) {
+	ans.Wpm = m.WPM
+	ans.Farnsworth = m.Farnsworth
+	ans.Frequency = m.Frequency
+	ans.Hinted = hinted
+}
+
+func (e *Engine) linesDone() bool {
+	return e.Lines > 0 && len(e.answers) >= e.Lines
+}
+
+// end is the one way a session ends, whether it's from `quit, running out of
+// lines, time, or input, or an interrupt. The session's saved if there are
+// stats to save it to.
+func (e *Engine) end(reason EndReason) (*Result, error) {
+	e.Output.Ended(reason)
+	res := &Result{Reason: reason, Answers: e.answers}
+
+	// Like with a block nobody copied, there's nothing to save if no lines
+	// were answered, and saving it anyway would keep a streak going
+	// without any practice.
+	if e.Stats != nil && len(e.answers) == 0 {
+		e.Output.Say("Nothing was answered, so there's nothing to save.")
+	} else if e.Stats != nil {
+		e.lockStats()
+		sum, err := e.save()
+		e.unlockStats()
+		if err != nil {
+			return res, err
+		}
+		res.Summary = &sum
+		e.Output.Saved(sum)
+	}
+	if e.Hooks.Ended != nil {
+		e.Hooks.Ended(res)
+	}
+	return res, nil
+}
+
+func (e *Engine) lockStats() {
+	if e.StatsLock != nil {
+		e.StatsLock.Lock()
+	}
+}
+
+func (e *Engine) unlockStats() {
+	if e.StatsLock != nil {
+		e.StatsLock.Unlock()
+	}
+}
+
+// save summarizes the session's answers and saves them to the user's
+// statistics.
+func (e *Engine) save() (stats.Summary, error) {
+	perc, dur, tries := e.answers.Averages()
+	sum := stats.NewSummary(time.Now(), e.Morse.Mode, perc, dur, tries, len(e.answers), e.Morse.WPM, e.Morse.Farnsworth)
+	sum.Duration = time.Since(e.started)
+	sum.Answers = make([]stats.Answer, len(e.answers))
+	for i, ans := range e.answers {
+		sum.Answers[i] = stats.NewAnswer(ans)
+	}
+	e.Stats.Add(sum)
+	return sum, e.Stats.Save()
+}
@@ -0,0 +1,261 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"github.com/ctdk/morseudar/internal/stats"
+	"github.com/ctdk/morseudar/internal/wordlists"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type chanInput chan string
+
+func (ci chanInput) Answers() <-chan string {
+	return ci
+}
+
+// answers makes input that gives the answers, then runs out.
+func answers(ans ...string) chanInput {
+	ci := make(chanInput, len(ans))
+	for _, a := range ans {
+		ci <- a + "\n"
+	}
+	close(ci)
+	return ci
+}
+
+type recordedOutput struct {
+	lines []Status
+	scored []compare.Answer
+	said []string
+	ended []EndReason
+	saved []stats.Summary
+}
+
+func (ro *recordedOutput) Line(st Status) { ro.lines = append(ro.lines, st) }
+func (ro *recordedOutput) Prompt() {}
+func (ro *recordedOutput) Scored(ans compare.Answer) { ro.scored = append(ro.scored, ans) }
+func (ro *recordedOutput) Say(text string) { ro.said = append(ro.said, text) }
+func (ro *recordedOutput) Ended(reason EndReason) { ro.ended = append(ro.ended, reason) }
+func (ro *recordedOutput) Saved(sum stats.Summary) { ro.saved = append(ro.saved, sum) }
+
+func newEngine(t *testing.T, in Input) (*Engine, *recordedOutput) {
+	t.Helper()
+	m, err := morse.New(morse.MorseChar, 60, 0, 700, false, false, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Silent = true
+	m.TestingMaterial = wordlists.GetChars(m.Src())
+	out := new(recordedOutput)
+	return New(m, compare.New(), in, out), out
+}
+
+func TestRunLines(t *testing.T) {
+	e, out := newEngine(t, answers("e", "`skip", "t"))
+	e.Lines = 2
+	var hooked []compare.Answer
+	e.Hooks.Answered = func(ans compare.Answer) { hooked = append(hooked, ans) }
+
+	res, err := e.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Reason != LinesDone {
+		t.Errorf("session should have ended with LinesDone, ended with %s", res.Reason)
+	}
+	if len(res.Answers) != 2 || len(hooked) != 2 || len(out.scored) != 1 {
+		t.Fatalf("expected 2 answers, 1 of them scored, got %d answers, %d hooked, and %d scored", len(res.Answers), len(hooked), len(out.scored))
+	}
+	if res.Answers[0].Response != "e" || res.Answers[0].Wpm != 60 || !res.Answers[1].Skipped {
+		t.Errorf("unexpected answers %+v", res.Answers)
+	}
+	if len(out.lines) != 2 || out.lines[1].String() != "# 2 of 2" {
+		t.Errorf("unexpected line headers %v", out.lines)
+	}
+	if res.Summary != nil {
+		t.Error("nothing should have been saved without stats")
+	}
+}
+
+func TestRunCommands(t *testing.T) {
+	e, out := newEngine(t, answers("`wpm 50", "`bogus", "`hint", "`quit"))
+	changed := 0
+	sent := 0
+	e.Hooks.SettingsChanged = func(wpm int, farn int, freq float64) {
+		changed++
+		if wpm != 50 {
+			t.Errorf("speed should have changed to 50 wpm, changed to %d", wpm)
+		}
+	}
+	e.Hooks.LineSent = func(st Status, line string) { sent++ }
+	e.Hooks.Sending = func(st Status, ml morsestrings.MorseString, dur time.Duration) {
+		// one character at 60 wpm is at least a dit and a word space
+		if dur < 160 * time.Millisecond {
+			t.Errorf("sending '%s' should have taken longer than %s", ml.RawString(), dur)
+		}
+	}
+
+	res, err := e.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Reason != Quit || len(res.Answers) != 0 {
+		t.Errorf("expected to quit without any answers, got %s with %d", res.Reason, len(res.Answers))
+	}
+	// changing the speed sends the line again, but unknown commands and
+	// hints don't
+	if changed != 1 || sent != 2 {
+		t.Errorf("expected the settings to change once and the line to be sent twice, got %d and %d", changed, sent)
+	}
+	if !strings.Contains(strings.Join(out.said, "\n"), "Unknown command '`bogus'") {
+		t.Errorf("the unknown command should have been pointed out, said %q", out.said)
+	}
+	if len(out.ended) != 1 || out.ended[0] != Quit {
+		t.Errorf("Ended should have been called once with Quit, got %v", out.ended)
+	}
+}
+
+func TestRunSaves(t *testing.T) {
+	e, out := newEngine(t, answers("e"))
+	p := filepath.Join(t.TempDir(), "stats")
+	u, err := stats.Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Stats = u
+
+	res, err := e.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Reason != NoMoreInput || res.Summary == nil || len(out.saved) != 1 {
+		t.Fatalf("session should have ended when the input ran out and been saved, got %+v", res)
+	}
+	if res.Summary.Count != 1 || len(res.Summary.Answers) != 1 {
+		t.Errorf("summary should have had the one answer, got %+v", res.Summary)
+	}
+	if _, err = os.Stat(p); err != nil {
+		t.Errorf("stats weren't saved: %s", err)
+	}
+}
+
+func TestRunSavesNothingUnanswered(t *testing.T) {
+	e, out := newEngine(t, answers("`quit"))
+	p := filepath.Join(t.TempDir(), "stats")
+	u, err := stats.Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Stats = u
+
+	res, err := e.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Reason != Quit || res.Summary != nil || len(out.saved) != 0 || len(u.Summaries) != 0 {
+		t.Fatalf("quitting without answering anything shouldn't have saved a session, got %+v", res)
+	}
+	if !strings.Contains(strings.Join(out.said, "\n"), "nothing to save") {
+		t.Errorf("should have said nothing was saved, said %q", out.said)
+	}
+	if _, err = os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("the stats file shouldn't have been written, got %v", err)
+	}
+}
+
+func TestRunTimeUp(t *testing.T) {
+	e, _ := newEngine(t, make(chanInput))
+	e.Duration = 500 * time.Millisecond
+	res, err := e.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Reason != TimeUp {
+		t.Errorf("session should have ended with TimeUp, ended with %s", res.Reason)
+	}
+
+	e.Duration = -1
+	if _, err = e.Run(); err == nil {
+		t.Error("a negative duration should have been rejected")
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	run := func() []byte {
+		e, _ := newEngine(t, answers("e", "`skip", "`wpm 30", "t"))
+		var buf bytes.Buffer
+		out := NewJSONOutput(&buf)
+		e.Output = out
+		e.Hooks = out.Hooks()
+		if _, err := e.Run(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	first := run()
+	if second := run(); !bytes.Equal(first, second) {
+		t.Errorf("the same answers and seed should give the same output, got\n%s\nand\n%s", first, second)
+	}
+
+	var types []string
+	var recs []map[string]any
+	for _, l := range bytes.Split(bytes.TrimSpace(first), []byte("\n")) {
+		rec := make(map[string]any)
+		if err := json.Unmarshal(l, &rec); err != nil {
+			t.Fatalf("'%s' isn't JSON: %s", l, err)
+		}
+		types = append(types, rec["type"].(string))
+		recs = append(recs, rec)
+	}
+	want := "line answer line answer message line message line answer line end result"
+	if got := strings.Join(types, " "); got != want {
+		t.Fatalf("expected records '%s', got '%s'", want, got)
+	}
+	if recs[3]["skipped"] != true || recs[3]["response"] != "" {
+		t.Errorf("second answer should have been skipped, got %v", recs[3])
+	}
+	if recs[8]["wpm"] != 30.0 || recs[8]["tries"] != 2.0 {
+		t.Errorf("last answer should have been sent twice, at 30 wpm, got %v", recs[8])
+	}
+	if recs[11]["count"] != 3.0 {
+		t.Errorf("result should have counted 3 answers, got %v", recs[11])
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := map[string]Status{
+		"# 3": {Num: 3},
+		"# 3 of 10": {Num: 3, Of: 10},
+		"# 3 (1m30s left)": {Num: 3, Timed: true, Left: 90 * time.Second},
+		"# 3 of 10 (5s left)": {Num: 3, Of: 10, Timed: true, Left: 5 * time.Second},
+	}
+	for want, st := range tests {
+		if st.String() != want {
+			t.Errorf("expected '%s', got '%s'", want, st)
+		}
+	}
+}
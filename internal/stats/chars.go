@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stats
+
+import (
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// CharErrorRate is how often a character was missed when it was sent.
+type CharErrorRate struct {
+	Char rune
+	Sent int
+	Missed int
+}
+
+// Rate returns the fraction of the times the character was sent that it was
+// missed.
+func (c CharErrorRate) Rate() float64 {
+	if c.Sent == 0 {
+		return 0
+	}
+	return float64(c.Missed) / float64(c.Sent)
+}
+
+// CharResults lines up a response with the original and returns each
+// character of the original, lowercased and leaving out spaces, along with
+// whether it was copied correctly.
+func CharResults(orig string, resp string) ([]rune, []bool) {
+	o := []rune(strings.ToLower(orig))
+	copied := compare.AlignChars(o, []rune(strings.ToLower(resp)))
+
+	chars := make([]rune, 0, len(o))
+	results := make([]bool, 0, len(o))
+	for i, r := range o {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		chars = append(chars, r)
+		results = append(results, copied[i])
+	}
+	return chars, results
+}
+
+// CharErrorRates works out how often each character was missed in the answers
+// given since the given time, worst first. If the stats are kept somewhere
+// that can work this out itself, it's asked to do that instead of going
+// through every answer.
+func (u *UserStats) CharErrorRates(since time.Time) ([]CharErrorRate, error) {
+	if q, ok := u.store.(Querier); ok {
+		return q.CharErrorRates(since)
+	}
+
+	rates := make(map[rune]*CharErrorRate)
+	for _, s := range u.Summaries {
+		for _, a := range s.Answers {
+			if a.Date.Before(since) {
+				continue
+			}
+			chars, copied := CharResults(a.Original, a.Response)
+			for i, r := range chars {
+				c, ok := rates[r]
+				if !ok {
+					c = &CharErrorRate{Char: r}
+					rates[r] = c
+				}
+				c.Sent++
+				if !copied[i] {
+					c.Missed++
+				}
+			}
+		}
+	}
+
+	cer := make([]CharErrorRate, 0, len(rates))
+	for _, c := range rates {
+		cer = append(cer, *c)
+	}
+	sortCharErrorRates(cer)
+	return cer, nil
+}
+
+func sortCharErrorRates(cer []CharErrorRate) {
+	sort.Slice(cer, func(i, j int) bool {
+		if ri, rj := cer[i].Rate(), cer[j].Rate(); ri != rj {
+			return ri > rj
+		}
+		if cer[i].Sent != cer[j].Sent {
+			return cer[i].Sent > cer[j].Sent
+		}
+		return cer[i].Char < cer[j].Char
+	})
+}
@@ -0,0 +1,334 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/ctdk/morseudar/internal/morse"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Exporting and importing stats as JSON and CSV, so they can be read by people
+// and spreadsheets and moved around without worrying about gob. Durations are
+// written out as seconds and modes by name, and percentages are left as
+// fractions between 0 and 1, like they are in the stats themselves. Goals are
+// exported with the JSON, but the profile's settings aren't.
+
+type jsonStats struct {
+	Username string `json:"username"`
+	Version string `json:"version"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+	Summaries []JSONSummary `json:"summaries"`
+	Goals []jsonGoal `json:"goals,omitempty"`
+}
+
+type jsonGoal struct {
+	Kind string `json:"kind"`
+	Period string `json:"period,omitempty"`
+	Amount int `json:"amount"`
+	Accuracy float64 `json:"accuracy,omitempty"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+var (
+	goalKindNames = map[GoalKind]string{PracticeTime: "time", PracticeLines: "lines", ReachSpeed: "speed"}
+	goalPeriodNames = map[GoalPeriod]string{NoPeriod: "", Daily: "day", Weekly: "week"}
+)
+
+// JSONSummary is a session the way it's exported as JSON, which is handy for
+// anything else handing out sessions as JSON too.
+type JSONSummary struct {
+	Date time.Time `json:"date"`
+	Mode string `json:"mode"`
+	AvgCorrect float64 `json:"avg_correct"`
+	AvgSeconds float64 `json:"avg_seconds"`
+	AvgTries float64 `json:"avg_tries"`
+	Count int `json:"count"`
+	Wpm int `json:"wpm"`
+	Farnsworth int `json:"farnsworth"`
+	// DurationSeconds is how long the whole session went on for.
+	DurationSeconds float64 `json:"duration_seconds"`
+	Answers []JSONAnswer `json:"answers,omitempty"`
+}
+
+// JSONAnswer is an answer the way it's exported as JSON.
+type JSONAnswer struct {
+	Date time.Time `json:"date"`
+	Original string `json:"original"`
+	Response string `json:"response"`
+	Correct float64 `json:"correct"`
+	Seconds float64 `json:"seconds"`
+	Tries int `json:"tries"`
+	Wpm int `json:"wpm"`
+	Farnsworth int `json:"farnsworth"`
+	Frequency float64 `json:"frequency"`
+	Hinted bool `json:"hinted"`
+	Skipped bool `json:"skipped"`
+}
+
+var csvHeader = []string{"record", "session", "date", "mode", "correct", "seconds", "tries", "count", "wpm", "farnsworth", "frequency", "hinted", "skipped", "original", "response", "duration_seconds"}
+
+const (
+	csvSummary = "summary"
+	csvAnswer = "answer"
+)
+
+// ExportJSON writes the stats out as JSON.
+func (u *UserStats) ExportJSON(w io.Writer) error {
+	js := jsonStats{Username: u.Username, Version: u.Version, Created: u.Created, Updated: u.Updated}
+	js.Summaries = make([]JSONSummary, len(u.Summaries))
+
+	for i, s := range u.Summaries {
+		js.Summaries[i] = NewJSONSummary(s)
+	}
+	for _, g := range u.Goals {
+		jg := jsonGoal{Kind: goalKindNames[g.Kind], Period: goalPeriodNames[g.Period], Amount: g.Amount, Accuracy: g.Accuracy, Created: g.Created}
+		if !g.Deadline.IsZero() {
+			jg.Deadline = &g.Deadline
+		}
+		js.Goals = append(js.Goals, jg)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(js)
+}
+
+// NewJSONSummary converts a session, answers and all, for exporting as JSON.
+func NewJSONSummary(s Summary) JSONSummary {
+	jsum := JSONSummary{Date: s.Date, Mode: s.Mode.String(), AvgCorrect: s.AvgPerc, AvgSeconds: s.AvgDur.Seconds(), AvgTries: s.AvgTries, Count: s.Count, Wpm: s.Wpm, Farnsworth: s.Farnsworth, DurationSeconds: s.Duration.Seconds()}
+	for _, a := range s.Answers {
+		jsum.Answers = append(jsum.Answers, JSONAnswer{Date: a.Date, Original: a.Original, Response: a.Response, Correct: a.Percentage, Seconds: a.Took.Seconds(), Tries: a.Tries, Wpm: a.Wpm, Farnsworth: a.Farnsworth, Frequency: a.Frequency, Hinted: a.Hinted, Skipped: a.Skipped})
+	}
+	return jsum
+}
+
+// ImportJSON reads stats exported with ExportJSON. If there weren't any goals
+// exported, the imported stats' goals are nil.
+func ImportJSON(r io.Reader) (*UserStats, error) {
+	js := new(jsonStats)
+	if err := json.NewDecoder(r).Decode(js); err != nil {
+		return nil, err
+	}
+
+	u := New()
+	u.Username = js.Username
+	u.Created = js.Created
+	u.Updated = js.Updated
+
+	for i, jsum := range js.Summaries {
+		mode, err := morse.ParseMode(jsum.Mode)
+		if err != nil {
+			return nil, fmt.Errorf("summary %d has unknown mode '%s'", i + 1, jsum.Mode)
+		}
+		s := NewSummary(jsum.Date, mode, jsum.AvgCorrect, seconds(jsum.AvgSeconds), jsum.AvgTries, jsum.Count, jsum.Wpm, jsum.Farnsworth)
+		s.Duration = seconds(jsum.DurationSeconds)
+		for _, ja := range jsum.Answers {
+			s.Answers = append(s.Answers, Answer{Date: ja.Date, Original: ja.Original, Response: ja.Response, Percentage: ja.Correct, Took: seconds(ja.Seconds), Tries: ja.Tries, Wpm: ja.Wpm, Farnsworth: ja.Farnsworth, Frequency: ja.Frequency, Hinted: ja.Hinted, Skipped: ja.Skipped})
+		}
+		u.Summaries = append(u.Summaries, s)
+	}
+
+	for i, jg := range js.Goals {
+		g := Goal{Amount: jg.Amount, Accuracy: jg.Accuracy, Created: jg.Created}
+		var ok bool
+		if g.Kind, ok = goalKind(jg.Kind); !ok {
+			return nil, fmt.Errorf("goal %d has unknown kind '%s'", i + 1, jg.Kind)
+		}
+		if g.Period, ok = goalPeriod(jg.Period); !ok {
+			return nil, fmt.Errorf("goal %d has unknown period '%s'", i + 1, jg.Period)
+		}
+		if jg.Deadline != nil {
+			g.Deadline = *jg.Deadline
+		}
+		u.Goals = append(u.Goals, g)
+	}
+
+	return u, nil
+}
+
+func goalKind(name string) (GoalKind, bool) {
+	for k, n := range goalKindNames {
+		if n == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+func goalPeriod(name string) (GoalPeriod, bool) {
+	for p, n := range goalPeriodNames {
+		if n == name {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// ExportCSV writes the stats out as CSV. Each session gets a "summary" row,
+// followed by an "answer" row for each of its answers, so they can easily be
+// filtered apart in a spreadsheet. The session column ties the answers to
+// their session. The username and creation times don't fit anywhere and are
+// left out.
+func (u *UserStats) ExportCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for i, s := range u.Summaries {
+		session := strconv.Itoa(i + 1)
+		mode := s.Mode.String()
+		row := []string{csvSummary, session, formatTime(s.Date), mode, formatFloat(s.AvgPerc), formatFloat(s.AvgDur.Seconds()), formatFloat(s.AvgTries), strconv.Itoa(s.Count), strconv.Itoa(s.Wpm), strconv.Itoa(s.Farnsworth), "", "", "", "", "", formatFloat(s.Duration.Seconds())}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		for _, a := range s.Answers {
+			row = []string{csvAnswer, session, formatTime(a.Date), mode, formatFloat(a.Percentage), formatFloat(a.Took.Seconds()), strconv.Itoa(a.Tries), "", strconv.Itoa(a.Wpm), strconv.Itoa(a.Farnsworth), formatFloat(a.Frequency), strconv.FormatBool(a.Hinted), strconv.FormatBool(a.Skipped), a.Original, a.Response, ""}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportCSV reads stats exported with ExportCSV. Since the CSV doesn't have
+// them, the username is the current user's, and the created and updated times
+// come from the first and last sessions.
+func ImportCSV(r io.Reader) (*UserStats, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	// exports from before the session length was kept don't have the
+	// last column
+	if (len(header) != len(csvHeader) && len(header) != len(csvHeader) - 1) || header[0] != csvHeader[0] {
+		return nil, fmt.Errorf("CSV header doesn't look like a morseudar stats export")
+	}
+	hasDuration := len(header) == len(csvHeader)
+
+	u := New()
+	sessions := make(map[string]int)
+
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		p := &csvParser{row: row}
+		switch row[0] {
+		case csvSummary:
+			mode, merr := morse.ParseMode(row[3])
+			if merr != nil {
+				return nil, fmt.Errorf("line %d: unknown mode '%s'", line, row[3])
+			}
+			s := NewSummary(p.time(2), mode, p.float(4), seconds(p.float(5)), p.float(6), p.int(7), p.int(8), p.int(9))
+			if hasDuration {
+				s.Duration = seconds(p.float(15))
+			}
+			if p.err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, p.err)
+			}
+			sessions[row[1]] = len(u.Summaries)
+			u.Summaries = append(u.Summaries, s)
+		case csvAnswer:
+			idx, ok := sessions[row[1]]
+			if !ok {
+				return nil, fmt.Errorf("line %d: answer for session %s comes before the session's summary", line, row[1])
+			}
+			a := Answer{Date: p.time(2), Percentage: p.float(4), Took: seconds(p.float(5)), Tries: p.int(6), Wpm: p.int(8), Farnsworth: p.int(9), Frequency: p.float(10), Hinted: p.bool(11), Skipped: p.bool(12), Original: row[13], Response: row[14]}
+			if p.err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, p.err)
+			}
+			u.Summaries[idx].Answers = append(u.Summaries[idx].Answers, a)
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type '%s'", line, row[0])
+		}
+	}
+
+	if len(u.Summaries) > 0 {
+		u.Created = u.Summaries[0].Date
+		u.Updated = u.Summaries[len(u.Summaries)-1].Date
+	}
+
+	return u, nil
+}
+
+// csvParser parses the fields of a row, hanging on to the first error so the
+// whole row can be checked at once.
+type csvParser struct {
+	row []string
+	err error
+}
+
+func (p *csvParser) float(i int) float64 {
+	f, err := strconv.ParseFloat(p.row[i], 64)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("invalid %s '%s'", csvHeader[i], p.row[i])
+	}
+	return f
+}
+
+func (p *csvParser) int(i int) int {
+	n, err := strconv.Atoi(p.row[i])
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("invalid %s '%s'", csvHeader[i], p.row[i])
+	}
+	return n
+}
+
+func (p *csvParser) bool(i int) bool {
+	b, err := strconv.ParseBool(p.row[i])
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("invalid %s '%s'", csvHeader[i], p.row[i])
+	}
+	return b
+}
+
+func (p *csvParser) time(i int) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, p.row[i])
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("invalid %s '%s'", csvHeader[i], p.row[i])
+	}
+	return t
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
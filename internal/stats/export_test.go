@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"bytes"
+	"github.com/ctdk/morseudar/internal/morse"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportTestStats() *UserStats {
+	u := New()
+	u.Username = "dawg"
+	start := time.Date(2025, 3, 14, 19, 30, 0, 0, time.UTC)
+
+	s1 := NewSummary(start, morse.TopWords, 0.875, time.Millisecond * 3250, 1.5, 2, 15, 10)
+	s1.Answers = []Answer{
+		{Date: start.Add(-time.Minute), Original: "hello", Response: "hello", Percentage: 1, Took: time.Second * 2, Tries: 1, Wpm: 15, Farnsworth: 10, Frequency: 700},
+		{Date: start.Add(-time.Second * 30), Original: "quoth, \"the raven\"", Response: "quoth the raven", Percentage: 0.75, Took: time.Millisecond * 4500, Tries: 2, Wpm: 15, Farnsworth: 10, Frequency: 700, Hinted: true},
+	}
+	s2 := NewSummary(start.Add(time.Hour * 24), morse.Qcode, 0.5, time.Second, 1, 1, 20, 0)
+	s2.Answers = []Answer{
+		{Date: start.Add(time.Hour * 24), Original: "qrs", Took: time.Second, Tries: 1, Wpm: 20, Frequency: 650, Skipped: true},
+	}
+	s1.Duration = time.Minute * 12 + time.Millisecond * 500
+	s2.Duration = time.Second * 90
+	u.Add(s1)
+	u.Add(s2)
+	daily, _ := ParseGoal("15 minutes a day", start)
+	speed, _ := ParseGoal("20 wpm at 85% by 2025-06-01", start)
+	u.AddGoal(daily)
+	u.AddGoal(speed)
+	u.Created = start.Add(-time.Hour)
+	u.Updated = start.Add(time.Hour * 24)
+	return u
+}
+
+func checkImported(t *testing.T, u *UserStats, imp *UserStats) {
+	if len(imp.Summaries) != len(u.Summaries) {
+		t.Fatalf("imported %d summaries, expected %d", len(imp.Summaries), len(u.Summaries))
+	}
+	for i, s := range u.Summaries {
+		is := imp.Summaries[i]
+		if !is.Date.Equal(s.Date) || is.Mode != s.Mode || is.AvgPerc != s.AvgPerc || is.AvgDur != s.AvgDur || is.AvgTries != s.AvgTries || is.Count != s.Count || is.Wpm != s.Wpm || is.Farnsworth != s.Farnsworth || is.Duration != s.Duration {
+			t.Errorf("summary %d didn't survive: exported %+v, imported %+v", i, s, is)
+		}
+		if len(is.Answers) != len(s.Answers) {
+			t.Errorf("summary %d should have had %d answers, got %d", i, len(s.Answers), len(is.Answers))
+			continue
+		}
+		for j, a := range s.Answers {
+			ia := is.Answers[j]
+			if !ia.Date.Equal(a.Date) {
+				t.Errorf("summary %d answer %d date was %s, expected %s", i, j, ia.Date, a.Date)
+			}
+			ia.Date = a.Date
+			if ia != a {
+				t.Errorf("summary %d answer %d didn't survive: exported %+v, imported %+v", i, j, a, ia)
+			}
+		}
+	}
+}
+
+func TestJSONExportImport(t *testing.T) {
+	u := exportTestStats()
+	buf := new(bytes.Buffer)
+	if err := u.ExportJSON(buf); err != nil {
+		t.Fatalf("error exporting JSON: %s", err)
+	}
+	if !strings.Contains(buf.String(), `"mode": "TopWords"`) {
+		t.Errorf("JSON export should have had the mode by name: %s", buf.String())
+	}
+
+	imp, err := ImportJSON(buf)
+	if err != nil {
+		t.Fatalf("error importing JSON: %s", err)
+	}
+	checkImported(t, u, imp)
+	if imp.Username != u.Username || !imp.Created.Equal(u.Created) || !imp.Updated.Equal(u.Updated) {
+		t.Errorf("user info didn't survive: exported %s/%s/%s, imported %s/%s/%s", u.Username, u.Created, u.Updated, imp.Username, imp.Created, imp.Updated)
+	}
+	if len(imp.Goals) != len(u.Goals) {
+		t.Fatalf("imported %d goals, expected %d", len(imp.Goals), len(u.Goals))
+	}
+	for i, g := range u.Goals {
+		ig := imp.Goals[i]
+		if ig.Kind != g.Kind || ig.Period != g.Period || ig.Amount != g.Amount || ig.Accuracy != g.Accuracy || !ig.Deadline.Equal(g.Deadline) || !ig.Created.Equal(g.Created) {
+			t.Errorf("goal %d didn't survive: exported %+v, imported %+v", i, g, ig)
+		}
+	}
+
+	if _, err = ImportJSON(strings.NewReader(`{"goals": [{"kind": "naps", "amount": 3}]}`)); err == nil {
+		t.Error("importing a goal of an unknown kind should have failed")
+	}
+}
+
+func TestCSVExportImport(t *testing.T) {
+	u := exportTestStats()
+	buf := new(bytes.Buffer)
+	if err := u.ExportCSV(buf); err != nil {
+		t.Fatalf("error exporting CSV: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 {
+		t.Errorf("CSV export should have had a header, 2 summaries and 3 answers, got %d lines", len(lines))
+	}
+
+	imp, err := ImportCSV(buf)
+	if err != nil {
+		t.Fatalf("error importing CSV: %s", err)
+	}
+	checkImported(t, u, imp)
+	if !imp.Created.Equal(u.Summaries[0].Date) {
+		t.Errorf("imported CSV created time should have come from the first session")
+	}
+
+	// exports from before the session length was kept still import
+	old := new(bytes.Buffer)
+	for _, ln := range lines {
+		old.WriteString(ln[:strings.LastIndex(ln, ",")] + "\n")
+	}
+	if imp, err = ImportCSV(old); err != nil {
+		t.Fatalf("error importing CSV without session lengths: %s", err)
+	}
+	if len(imp.Summaries) != 2 || imp.Summaries[0].Duration != 0 || len(imp.Summaries[0].Answers) != 2 {
+		t.Errorf("CSV without session lengths didn't import right: %+v", imp.Summaries)
+	}
+}
+
+func TestCSVImportErrors(t *testing.T) {
+	bad := map[string]string{
+		"header": "foo,bar\n",
+		"mode": strings.Join(csvHeader, ",") + "\nsummary,1,2025-03-14T19:30:00Z,Bork,1,1,1,1,10,0,,,,,,60\n",
+		"orphan": strings.Join(csvHeader, ",") + "\nanswer,1,2025-03-14T19:30:00Z,TopWords,1,1,1,,10,0,700,false,false,a,a,\n",
+		"number": strings.Join(csvHeader, ",") + "\nsummary,1,2025-03-14T19:30:00Z,TopWords,lots,1,1,1,10,0,,,,,,60\n",
+	}
+	bad["duration"] = strings.Join(csvHeader, ",") + "\nsummary,1,2025-03-14T19:30:00Z,TopWords,1,1,1,1,10,0,,,,,,forever\n"
+	for name, c := range bad {
+		if _, err := ImportCSV(strings.NewReader(c)); err == nil {
+			t.Errorf("importing CSV with a bad %s should have failed", name)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stats
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Goals are practice targets, like "15 minutes a day", "200 lines a week", or
+// "20 wpm at 90% by june". Progress towards them is worked out from the saved
+// sessions, along with how many days in a row have had some practice.
+
+type GoalKind uint8
+
+const (
+	// PracticeTime is a goal of practicing for so many minutes a day or
+	// week.
+	PracticeTime GoalKind = iota
+	// PracticeLines is a goal of copying so many lines a day or week.
+	PracticeLines
+	// ReachSpeed is a goal of copying at a certain speed with a certain
+	// accuracy by a certain date.
+	ReachSpeed
+)
+
+type GoalPeriod uint8
+
+const (
+	NoPeriod GoalPeriod = iota
+	Daily
+	Weekly
+)
+
+// DefaultGoalAccuracy is the accuracy a speed goal needs if one isn't given.
+const DefaultGoalAccuracy = 0.9
+
+type Goal struct {
+	Kind GoalKind
+	Period GoalPeriod
+	// minutes, lines, or wpm, depending on the kind of goal
+	Amount int
+	Accuracy float64
+	Deadline time.Time
+	Created time.Time
+}
+
+// GoalProgress is how far along a goal is.
+type GoalProgress struct {
+	Goal Goal
+	Done float64
+	Met bool
+	// Expired is set for speed goals that weren't met by the deadline.
+	Expired bool
+	// DaysLeft is how many days are left before a speed goal's deadline,
+	// counting today.
+	DaysLeft int
+}
+
+var (
+	periodicGoal = regexp.MustCompile(`^(\d+)\s*(minutes?|mins?|m|hours?|hrs?|h|lines?)\s+(?:a|per|each)\s+(day|week)$`)
+	speedGoal = regexp.MustCompile(`^(?:reach\s+)?(\d+)\s*wpm(?:\s+at\s+(\d+(?:\.\d+)?)\s*%)?\s+by\s+(.+)$`)
+)
+
+// ParseGoal makes a goal out of a description like "15 minutes a day", "200
+// lines a week", or "20 wpm at 90% by june". Deadlines can be a date like
+// 2026-06-01, or a month, optionally with a year, which means the end of that
+// month. A month without a year is the next one to come around.
+func ParseGoal(desc string, now time.Time) (Goal, error) {
+	d := strings.Join(strings.Fields(strings.ToLower(desc)), " ")
+	g := Goal{Created: now}
+
+	if m := periodicGoal.FindStringSubmatch(d); m != nil {
+		g.Amount, _ = strconv.Atoi(m[1])
+		switch {
+		case strings.HasPrefix(m[2], "l"):
+			g.Kind = PracticeLines
+		case strings.HasPrefix(m[2], "h"):
+			g.Kind = PracticeTime
+			g.Amount *= 60
+		default:
+			g.Kind = PracticeTime
+		}
+		g.Period = Daily
+		if m[3] == "week" {
+			g.Period = Weekly
+		}
+		if g.Amount <= 0 {
+			return Goal{}, fmt.Errorf("goal '%s' needs to be for more than nothing", desc)
+		}
+		return g, nil
+	}
+
+	if m := speedGoal.FindStringSubmatch(d); m != nil {
+		g.Kind = ReachSpeed
+		g.Amount, _ = strconv.Atoi(m[1])
+		g.Accuracy = DefaultGoalAccuracy
+		if m[2] != "" {
+			acc, _ := strconv.ParseFloat(m[2], 64)
+			if acc <= 0 || acc > 100 {
+				return Goal{}, fmt.Errorf("accuracy in goal '%s' needs to be between 0 and 100%%", desc)
+			}
+			g.Accuracy = acc / 100
+		}
+		deadline, err := parseDeadline(m[3], now)
+		if err != nil {
+			return Goal{}, fmt.Errorf("goal '%s': %w", desc, err)
+		}
+		if deadline.Before(startOfDay(now)) {
+			return Goal{}, fmt.Errorf("goal '%s' has a deadline that's already passed", desc)
+		}
+		g.Deadline = deadline
+		if g.Amount <= 0 {
+			return Goal{}, fmt.Errorf("goal '%s' needs a speed", desc)
+		}
+		return g, nil
+	}
+
+	return Goal{}, fmt.Errorf("don't know what to make of the goal '%s'. Try something like '15 minutes a day', '200 lines a week', or '20 wpm at 90%% by june'", desc)
+}
+
+func parseDeadline(s string, now time.Time) (time.Time, error) {
+	loc := now.Location()
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"January 2006", "Jan 2006"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return endOfMonth(t), nil
+		}
+	}
+	for _, layout := range []string{"January", "Jan"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			year := now.Year()
+			if t.Month() < now.Month() {
+				year++
+			}
+			return endOfMonth(time.Date(year, t.Month(), 1, 0, 0, 0, 0, loc)), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't make a date out of '%s'", s)
+}
+
+// endOfMonth returns the start of the last day of the month.
+func endOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month() + 1, 0, 0, 0, 0, 0, t.Location())
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns the start of the Monday of the week.
+func startOfWeek(t time.Time) time.Time {
+	d := startOfDay(t)
+	return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+}
+
+func (g Goal) String() string {
+	period := "a day"
+	if g.Period == Weekly {
+		period = "a week"
+	}
+	switch g.Kind {
+	case PracticeTime:
+		return fmt.Sprintf("%d minutes %s", g.Amount, period)
+	case PracticeLines:
+		return fmt.Sprintf("%d lines %s", g.Amount, period)
+	default:
+		return fmt.Sprintf("%d wpm at %.0f%% by %s", g.Amount, g.Accuracy * 100, g.Deadline.Format("2006-01-02"))
+	}
+}
+
+// AddGoal adds a goal.
+func (u *UserStats) AddGoal(g Goal) {
+	u.Goals = append(u.Goals, g)
+	u.goalsChanged = true
+	u.Updated = time.Now()
+}
+
+// RemoveGoal removes the goal at index i.
+func (u *UserStats) RemoveGoal(i int) error {
+	if i < 0 || i >= len(u.Goals) {
+		return fmt.Errorf("there's no goal %d", i + 1)
+	}
+	u.Goals = append(u.Goals[:i], u.Goals[i+1:]...)
+	u.goalsChanged = true
+	u.Updated = time.Now()
+	return nil
+}
+
+// PracticeTime returns how long the session took. Sessions saved before that
+// was kept make do with the time spent answering.
+func (s Summary) PracticeTime() time.Duration {
+	if s.Duration > 0 {
+		return s.Duration
+	}
+	return s.AvgDur * time.Duration(s.Count)
+}
+
+// Progress works out how far along the goal is as of now.
+func (g Goal) Progress(u *UserStats, now time.Time) GoalProgress {
+	p := GoalProgress{Goal: g}
+
+	switch g.Kind {
+	case PracticeTime, PracticeLines:
+		since := startOfDay(now)
+		if g.Period == Weekly {
+			since = startOfWeek(now)
+		}
+		var minutes time.Duration
+		lines := 0
+		for _, s := range u.Summaries {
+			if s.Date.Before(since) {
+				continue
+			}
+			minutes += s.PracticeTime()
+			lines += s.Count
+		}
+		if g.Kind == PracticeTime {
+			p.Done = minutes.Minutes()
+		} else {
+			p.Done = float64(lines)
+		}
+		p.Met = p.Done >= float64(g.Amount)
+	case ReachSpeed:
+		for _, s := range u.Summaries {
+			if s.AvgPerc >= g.Accuracy && float64(s.Wpm) > p.Done {
+				p.Done = float64(s.Wpm)
+			}
+		}
+		p.Met = p.Done >= float64(g.Amount)
+		today := startOfDay(now)
+		p.Expired = !p.Met && today.After(g.Deadline)
+		if !p.Expired {
+			// rounded, in case a change to or from daylight saving
+			// time is in there
+			p.DaysLeft = int(g.Deadline.Sub(today).Hours() / 24 + 0.5) + 1
+		}
+	}
+
+	return p
+}
+
+func (p GoalProgress) String() string {
+	var s string
+	when := "today"
+	if p.Goal.Period == Weekly {
+		when = "this week"
+	}
+
+	switch p.Goal.Kind {
+	case PracticeTime:
+		s = fmt.Sprintf("%s: %.0f of %d minutes %s", p.Goal, p.Done, p.Goal.Amount, when)
+	case PracticeLines:
+		s = fmt.Sprintf("%s: %.0f of %d lines %s", p.Goal, p.Done, p.Goal.Amount, when)
+	case ReachSpeed:
+		s = fmt.Sprintf("%s: best so far %.0f wpm", p.Goal, p.Done)
+		switch {
+		case p.Met:
+		case p.Expired:
+			s += ", and the deadline's passed"
+		case p.DaysLeft == 1:
+			s += ", last day"
+		default:
+			s += fmt.Sprintf(", %d days left", p.DaysLeft)
+		}
+	}
+	if p.Met {
+		s += " - done!"
+	}
+	return s
+}
+
+// WriteProgress writes out the current streak and how the goals are coming
+// along. If numbered is set, each goal gets its number, for removing it.
+func (u *UserStats) WriteProgress(w io.Writer, now time.Time, numbered bool) {
+	current, longest := u.Streak(now)
+	if current > 0 || longest > 0 {
+		days := "days"
+		if current == 1 {
+			days = "day"
+		}
+		fmt.Fprintf(w, "Practice streak: %d %s (longest %d).\n", current, days, longest)
+	}
+	if len(u.Goals) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "Goals:")
+	for i, g := range u.Goals {
+		if numbered {
+			fmt.Fprintf(w, "%d. %s\n", i + 1, g.Progress(u, now))
+		} else {
+			fmt.Fprintf(w, "- %s\n", g.Progress(u, now))
+		}
+	}
+}
+
+// Streak returns how many days in a row, up to today, have had at least one
+// session, along with the longest streak there's been. A streak isn't broken
+// until a whole day goes by without any practice, so if there hasn't been a
+// session yet today but there was one yesterday, the streak's still going.
+func (u *UserStats) Streak(now time.Time) (int, int) {
+	loc := now.Location()
+	days := make(map[time.Time]bool)
+	for _, s := range u.Summaries {
+		days[startOfDay(s.Date.In(loc))] = true
+	}
+	if len(days) == 0 {
+		return 0, 0
+	}
+
+	current := 0
+	day := startOfDay(now)
+	if !days[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for days[day] {
+		current++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	sorted := make([]time.Time, 0, len(days))
+	for d := range days {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Before(sorted[j])
+	})
+	longest, run := 1, 1
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i-1].AddDate(0, 0, 1).Equal(sorted[i]) {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+
+	return current, longest
+}
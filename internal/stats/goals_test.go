@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stats
+
+import (
+	"github.com/ctdk/morseudar/internal/morse"
+	"testing"
+	"time"
+)
+
+// a Wednesday
+var goalNow = time.Date(2025, 3, 12, 18, 0, 0, 0, time.UTC)
+
+func TestParseGoal(t *testing.T) {
+	tests := []struct {
+		desc string
+		kind GoalKind
+		period GoalPeriod
+		amount int
+		accuracy float64
+		deadline string
+	}{
+		{"15 minutes a day", PracticeTime, Daily, 15, 0, ""},
+		{"1 hour per week", PracticeTime, Weekly, 60, 0, ""},
+		{"200 Lines a Week", PracticeLines, Weekly, 200, 0, ""},
+		{"reach 20 wpm at 90% by june", ReachSpeed, NoPeriod, 20, 0.9, "2025-06-30"},
+		{"25 wpm by feb", ReachSpeed, NoPeriod, 25, DefaultGoalAccuracy, "2026-02-28"},
+		{"18 wpm at 95% by 2025-04-01", ReachSpeed, NoPeriod, 18, 0.95, "2025-04-01"},
+		{"30 wpm at 80% by march 2026", ReachSpeed, NoPeriod, 30, 0.8, "2026-03-31"},
+	}
+	for _, v := range tests {
+		g, err := ParseGoal(v.desc, goalNow)
+		if err != nil {
+			t.Errorf("error parsing goal '%s': %s", v.desc, err)
+			continue
+		}
+		if g.Kind != v.kind || g.Period != v.period || g.Amount != v.amount || g.Accuracy != v.accuracy {
+			t.Errorf("goal '%s' parsed wrong: %+v", v.desc, g)
+		}
+		if v.deadline != "" && g.Deadline.Format("2006-01-02") != v.deadline {
+			t.Errorf("goal '%s' should have had deadline %s, got %s", v.desc, v.deadline, g.Deadline)
+		}
+	}
+
+	for _, bad := range []string{"practice more", "0 minutes a day", "20 wpm by 2024-01-01", "20 wpm at 120% by june", "20 wpm by someday"} {
+		if _, err := ParseGoal(bad, goalNow); err == nil {
+			t.Errorf("goal '%s' should have been rejected", bad)
+		}
+	}
+}
+
+func TestGoalProgress(t *testing.T) {
+	u := New()
+	add := func(d time.Time, count int, dur time.Duration, wpm int, perc float64) {
+		s := NewSummary(d, morse.TopWords, perc, time.Second, 1, count, wpm, 0)
+		s.Duration = dur
+		u.Add(s)
+	}
+	// last week, this Monday, and today
+	add(goalNow.AddDate(0, 0, -7), 100, time.Hour, 22, 0.85)
+	add(goalNow.AddDate(0, 0, -2), 50, 20 * time.Minute, 18, 0.95)
+	add(goalNow.Add(-time.Hour), 30, 10 * time.Minute, 16, 0.9)
+
+	daily, _ := ParseGoal("15 minutes a day", goalNow)
+	p := daily.Progress(u, goalNow)
+	if p.Done != 10 || p.Met {
+		t.Errorf("expected 10 of 15 minutes today and not done, got %+v", p)
+	}
+
+	weekly, _ := ParseGoal("80 lines a week", goalNow)
+	p = weekly.Progress(u, goalNow)
+	if p.Done != 80 || !p.Met {
+		t.Errorf("expected 80 lines this week and done, got %+v", p)
+	}
+
+	// the 22 wpm session wasn't accurate enough to count
+	speed, _ := ParseGoal("20 wpm at 90% by 2025-03-14", goalNow)
+	p = speed.Progress(u, goalNow)
+	if p.Done != 18 || p.Met || p.Expired || p.DaysLeft != 3 {
+		t.Errorf("expected best of 18 wpm with 3 days left, got %+v", p)
+	}
+	p = speed.Progress(u, goalNow.AddDate(0, 0, 3))
+	if !p.Expired {
+		t.Errorf("speed goal should have expired, got %+v", p)
+	}
+
+	// older sessions without a duration fall back on time spent answering
+	s := NewSummary(goalNow, morse.TopWords, 1, 3 * time.Second, 1, 20, 10, 0)
+	if s.PracticeTime() != time.Minute {
+		t.Errorf("expected a minute of practice time, got %s", s.PracticeTime())
+	}
+}
+
+func TestStreak(t *testing.T) {
+	u := New()
+	if c, l := u.Streak(goalNow); c != 0 || l != 0 {
+		t.Errorf("no sessions should mean no streak, got %d, %d", c, l)
+	}
+
+	for _, daysAgo := range []int{10, 9, 8, 7, 3, 2, 1} {
+		u.Add(NewSummary(goalNow.AddDate(0, 0, -daysAgo), morse.TopWords, 1, time.Second, 1, 1, 10, 0))
+	}
+	// nothing today yet, but the streak's still alive
+	if c, l := u.Streak(goalNow); c != 3 || l != 4 {
+		t.Errorf("expected a streak of 3 and longest of 4, got %d, %d", c, l)
+	}
+	u.Add(NewSummary(goalNow, morse.TopWords, 1, time.Second, 1, 1, 10, 0))
+	u.Add(NewSummary(goalNow.Add(-time.Hour), morse.TopWords, 1, time.Second, 1, 1, 10, 0))
+	if c, l := u.Streak(goalNow); c != 4 || l != 4 {
+		t.Errorf("expected a streak of 4 and longest of 4, got %d, %d", c, l)
+	}
+	if c, _ := u.Streak(goalNow.AddDate(0, 0, 2)); c != 0 {
+		t.Errorf("a missed day should break the streak, got %d", c)
+	}
+}
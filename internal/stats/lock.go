@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stats
+
+import (
+	"fmt"
+	"os"
+)
+
+// Stat files get saved by swapping a new file in for the old one, which keeps
+// a half written file from ever being seen, but if two sessions are running at
+// once the last one to save would win and the other one's sessions would be
+// lost. To keep that from happening, saving takes an advisory lock, reads in
+// whatever's been saved since the stats were loaded, and merges it in before
+// writing. The lock is on a separate file sitting next to the stat file, since
+// the stat file itself gets replaced on every save.
+
+type fileLock struct {
+	f *os.File
+}
+
+func lockStatFile(saveFile string) (*fileLock, error) {
+	f, err := os.OpenFile(saveFile + ".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking %s: %w", saveFile, err)
+	}
+	return &fileLock{f: f}, nil
+}
+
+func (l *fileLock) unlock() error {
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stats
+
+import (
+	"github.com/ctdk/morseudar/internal/morse"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSaveMerges(t *testing.T) {
+	saveFile := filepath.Join(t.TempDir(), "user-stats")
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// two sessions running at once, both starting from the same file
+	first, err := Load(saveFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Load(saveFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.Add(NewSummary(base, morse.TopWords, 0.9, time.Second, 1, 10, 15, 0))
+	second.Add(NewSummary(base.Add(time.Minute), morse.CodeGroup, 0.8, time.Second, 1, 10, 15, 0))
+	if err = first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err = second.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// saving again shouldn't duplicate anything
+	if err = first.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := Load(saveFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(u.Summaries) != 2 {
+		t.Fatalf("expected both sessions to be saved, got %d", len(u.Summaries))
+	}
+	if u.Summaries[0].Mode != morse.TopWords || u.Summaries[1].Mode != morse.CodeGroup {
+		t.Errorf("sessions weren't kept in the order they were saved: %v, %v", u.Summaries[0].Mode, u.Summaries[1].Mode)
+	}
+
+	// Replace doesn't merge.
+	second.Summaries = second.Summaries[:0]
+	if err = second.Replace(); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ = Load(saveFile); len(u.Summaries) != 0 {
+		t.Errorf("Replace should have left no sessions, got %d", len(u.Summaries))
+	}
+}
+
+// Saving a session shouldn't throw away goals or settings changed somewhere
+// else after the stats were loaded.
+func TestSaveKeepsProfileChanges(t *testing.T) {
+	for _, name := range []string{"user-stats", "stats.db"} {
+		t.Run(name, func(t *testing.T) {
+			saveFile := filepath.Join(t.TempDir(), name)
+			now := time.Now()
+
+			practicing, err := Load(saveFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer practicing.Close()
+			other, err := Load(saveFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer other.Close()
+
+			g, _ := ParseGoal("15 minutes a day", now)
+			other.AddGoal(g)
+			other.SetSettings(Settings{Wpm: 25, Mode: "codegroups"})
+			if err = other.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			practicing.Add(NewSummary(now, morse.CodeGroup, 0.9, time.Second, 1, 10, 15, 0))
+			if err = practicing.Save(); err != nil {
+				t.Fatal(err)
+			}
+			if len(practicing.Goals) != 1 || practicing.Settings.Wpm != 25 {
+				t.Errorf("saving should have picked up the other goals and settings, got %+v and %+v", practicing.Goals, practicing.Settings)
+			}
+
+			u, err := Load(saveFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer u.Close()
+			if len(u.Summaries) != 1 {
+				t.Errorf("expected the session to be saved, got %d", len(u.Summaries))
+			}
+			if len(u.Goals) != 1 || u.Goals[0].Amount != 15 {
+				t.Errorf("the goal added elsewhere was lost: %+v", u.Goals)
+			}
+			if u.Settings.Wpm != 25 || u.Settings.Mode != "codegroups" {
+				t.Errorf("the settings changed elsewhere were lost: %+v", u.Settings)
+			}
+
+			// removing the goal here should stick, though
+			if err = practicing.RemoveGoal(0); err != nil {
+				t.Fatal(err)
+			}
+			if err = practicing.Save(); err != nil {
+				t.Fatal(err)
+			}
+			if err = other.Save(); err != nil {
+				t.Fatal(err)
+			}
+			if u, err = Load(saveFile); err != nil {
+				t.Fatal(err)
+			}
+			defer u.Close()
+			if len(u.Goals) != 0 {
+				t.Errorf("the goal should have been removed, got %+v", u.Goals)
+			}
+		})
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	saveFile := filepath.Join(t.TempDir(), "user-stats")
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	n := 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			u, err := Load(saveFile)
+			if err != nil {
+				errs <- err
+				return
+			}
+			u.Add(NewSummary(base.Add(time.Duration(i) * time.Minute), morse.TopWords, 0.9, time.Second, 1, 10, 15, 0))
+			errs <- u.Save()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	u, err := Load(saveFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(u.Summaries) != n {
+		t.Errorf("expected %d sessions after saving them all at once, got %d", n, len(u.Summaries))
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := New()
+	a.Created = base
+	a.Add(NewSummary(base, morse.TopWords, 0.9, time.Second, 1, 10, 15, 0))
+	a.Updated = base.Add(time.Hour)
+
+	b := New()
+	b.Created = base.Add(-time.Hour)
+	b.Add(NewSummary(base, morse.TopWords, 0.9, time.Second, 1, 10, 15, 0))
+	b.Add(NewSummary(base, morse.CodeGroup, 0.7, time.Second, 1, 10, 15, 0))
+	b.Updated = base.Add(2 * time.Hour)
+
+	a.Merge(b)
+	if len(a.Summaries) != 2 {
+		t.Errorf("expected 2 sessions after merging, got %d", len(a.Summaries))
+	}
+	if !a.Created.Equal(b.Created) {
+		t.Errorf("merged stats should have the earliest creation time %s, got %s", b.Created, a.Created)
+	}
+	if !a.Updated.Equal(b.Updated) {
+		t.Errorf("merged stats should have the latest update time %s, got %s", b.Updated, a.Updated)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"fmt"
+	"os"
+)
+
+// Read reads the stats in a file, migrating them if needed, but unlike Load it
+// never writes anything back to the file. It's meant for stat files copied
+// over from somewhere else.
+func Read(saveFile string) (*UserStats, error) {
+	if IsDatabase(saveFile) {
+		// opening a database that isn't there would make a new one
+		if _, err := os.Stat(saveFile); err != nil {
+			return nil, err
+		}
+		st, err := OpenSQLStore(saveFile)
+		if err != nil {
+			return nil, err
+		}
+		defer st.Close()
+		u, err := st.Load()
+		if err != nil {
+			return nil, err
+		}
+		if err = st.LoadAnswers(u); err != nil {
+			return nil, err
+		}
+		u.store = nil
+		return u, nil
+	}
+
+	raw, err := os.ReadFile(saveFile)
+	if err != nil {
+		return nil, err
+	}
+	u, _, err := decodeStats(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", saveFile, err)
+	}
+	return u, nil
+}
+
+// MergeFiles reads the stat files and combines them into one set of stats,
+// dropping any duplicate sessions along the way. The username, settings, and
+// goals come from the first file. It also returns how many duplicates were dropped.
+func MergeFiles(saveFiles ...string) (*UserStats, int, error) {
+	merged := new(UserStats)
+	merged.Version = StatVersion
+	merged.Summaries = make([]Summary, 0)
+	total := 0
+
+	for i, f := range saveFiles {
+		u, err := Read(f)
+		if err != nil {
+			return nil, 0, err
+		}
+		if i == 0 {
+			merged.Username = u.Username
+			merged.Settings = u.Settings
+			merged.Goals = u.Goals
+		}
+		total += len(u.Summaries)
+		merged.Merge(u)
+	}
+
+	return merged, total - len(merged.Summaries), nil
+}
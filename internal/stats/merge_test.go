@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stats
+
+import (
+	"github.com/ctdk/morseudar/internal/morse"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMergeFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	desktop := New()
+	desktop.Username = "desktop"
+	desktop.Add(NewSummary(base, morse.TopWords, 0.9, time.Second, 1, 10, 15, 0))
+	desktop.Add(NewSummary(base.Add(time.Hour), morse.TopWords, 0.8, time.Second, 1, 10, 15, 0))
+	desktop.Created = base
+	desktop.Updated = base.Add(time.Hour)
+
+	// the laptop has a copy of the first desktop session, plus one of its
+	// own that started at the same time but in a different mode
+	laptop := New()
+	laptop.Username = "laptop"
+	laptop.Add(NewSummary(base, morse.TopWords, 0.9, time.Second, 1, 10, 15, 0))
+	laptop.Add(NewSummary(base, morse.CodeGroup, 0.7, time.Second, 1, 10, 15, 0))
+	laptop.Created = base.Add(-24 * time.Hour)
+	laptop.Updated = base.Add(2 * time.Hour)
+
+	files := []string{filepath.Join(dir, "desktop"), filepath.Join(dir, "laptop")}
+	for i, u := range []*UserStats{desktop, laptop} {
+		if err := u.Replace(files[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merged, dups, err := MergeFiles(files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Summaries) != 3 {
+		t.Errorf("expected 3 sessions after merging, got %d", len(merged.Summaries))
+	}
+	if dups != 1 {
+		t.Errorf("expected 1 duplicate, got %d", dups)
+	}
+	if merged.Username != "desktop" {
+		t.Errorf("username should have come from the first file, got '%s'", merged.Username)
+	}
+	if !merged.Created.Equal(laptop.Created) || !merged.Updated.Equal(laptop.Updated) {
+		t.Errorf("expected created %s and updated %s, got %s and %s", laptop.Created, laptop.Updated, merged.Created, merged.Updated)
+	}
+
+	if _, _, err = MergeFiles(files[0], filepath.Join(dir, "nope")); err == nil {
+		t.Error("merging a missing file should have failed")
+	}
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migration upgrades stats from one version of the stat file to the next.
+// Since gob quietly leaves out fields that don't exist any more and leaves new
+// ones empty, most migrations only need to fill in the new fields on the
+// already decoded stats. A migration that needs to get at fields that have
+// since been removed or changed can decode the raw file into its own copy of
+// the old types.
+type migration struct {
+	from string
+	to string
+	migrate func(u *UserStats, raw []byte) error
+}
+
+// migrations must stay in order, each one picking up where the last left off.
+var migrations = []migration{
+	{ from: "0.1.0", to: "0.2.0", migrate: migrate010to020 },
+	{ from: "0.2.0", to: "0.3.0", migrate: migrate020to030 },
+	{ from: "0.3.0", to: "0.4.0", migrate: migrate030to040 },
+}
+
+// NewerVersionError is returned when trying to load a stat file written by a
+// newer version of morseudar than this one.
+type NewerVersionError struct {
+	Version string
+}
+
+func (e *NewerVersionError) Error() string {
+	return fmt.Sprintf("the stat file is version %s, but this version of morseudar only understands up to version %s. Please upgrade morseudar to use it.", e.Version, StatVersion)
+}
+
+// statVersion is just enough of UserStats to find out what version a stat file
+// is before trying to decode the whole thing.
+type statVersion struct {
+	Version string
+}
+
+// decodeStats checks the version of the raw stat file, decodes it, and runs it
+// through any migrations needed to bring it up to date. It returns the version
+// the file was originally at along with the stats.
+func decodeStats(raw []byte) (*UserStats, string, error) {
+	sv := new(statVersion)
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(sv); err != nil {
+		return nil, "", err
+	}
+
+	cmp, err := compareVersions(sv.Version, StatVersion)
+	if err != nil {
+		return nil, "", err
+	}
+	if cmp > 0 {
+		return nil, "", &NewerVersionError{Version: sv.Version}
+	}
+
+	u := new(UserStats)
+	if err = gob.NewDecoder(bytes.NewReader(raw)).Decode(u); err != nil {
+		return nil, "", err
+	}
+
+	if err = u.migrate(raw); err != nil {
+		return nil, "", err
+	}
+
+	return u, sv.Version, nil
+}
+
+// migrate runs the stats through each migration in turn, starting from the
+// version they're at now.
+func (u *UserStats) migrate(raw []byte) error {
+	for _, m := range migrations {
+		if u.Version != m.from {
+			continue
+		}
+		if err := m.migrate(u, raw); err != nil {
+			return fmt.Errorf("migrating stats from version %s to %s: %w", m.from, m.to, err)
+		}
+		u.Version = m.to
+	}
+
+	if u.Version != StatVersion {
+		return fmt.Errorf("don't know how to migrate stat file version '%s'", u.Version)
+	}
+	return nil
+}
+
+// backup writes a copy of the raw stat file from before it was migrated next
+// to the stat file, named after the version it was. If there's already a
+// backup for that version, it won't be overwritten.
+func backup(saveFile string, version string, raw []byte) (string, error) {
+	bak := fmt.Sprintf("%s.%s.bak", saveFile, version)
+	if _, err := os.Stat(bak); err == nil {
+		bak = fmt.Sprintf("%s.%s.%d.bak", saveFile, version, time.Now().Unix())
+	}
+
+	if err := os.WriteFile(bak, raw, 0644); err != nil {
+		return "", err
+	}
+	return bak, nil
+}
+
+// compareVersions compares two x.y.z version strings, returning -1, 0, or 1 if
+// a is older, the same as, or newer than b.
+func compareVersions(a string, b string) (int, error) {
+	av, err := splitVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bv, err := splitVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range av {
+		if av[i] < bv[i] {
+			return -1, nil
+		} else if av[i] > bv[i] {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func splitVersion(v string) ([3]int, error) {
+	var ver [3]int
+	parts := strings.Split(v, ".")
+	if len(parts) != len(ver) {
+		return ver, fmt.Errorf("invalid stat file version '%s'", v)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return ver, fmt.Errorf("invalid stat file version '%s'", v)
+		}
+		ver[i] = n
+	}
+	return ver, nil
+}
+
+// 0.1.0 didn't keep the individual answers from each session, so there's
+// nothing to fill in for them.
+func migrate010to020(u *UserStats, raw []byte) error {
+	return nil
+}
+
+// 0.2.0 didn't have per-profile settings, and empty settings just mean the
+// usual defaults get used.
+func migrate020to030(u *UserStats, raw []byte) error {
+	return nil
+}
+
+// 0.3.0 didn't have goals or session durations. There aren't any goals to
+// fill in, and without a duration PracticeTime falls back on the time spent
+// answering.
+func migrate030to040(u *UserStats, raw []byte) error {
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"encoding/gob"
+	"errors"
+	"github.com/ctdk/morseudar/internal/morse"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// what the stat file looked like at version 0.1.0
+type userStatsV010 struct {
+	Username string
+	Summaries []summaryV010
+	Version string
+	Created time.Time
+	Updated time.Time
+}
+
+type summaryV010 struct {
+	Date time.Time
+	Mode morse.MorseMode
+	AvgPerc float64
+	AvgDur time.Duration
+	AvgTries float64
+	Count int
+	Wpm int
+	Farnsworth int
+}
+
+func writeStatFile(t *testing.T, path string, v interface{}) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("error creating stat file: %s", err)
+	}
+	defer f.Close()
+	if err = gob.NewEncoder(f).Encode(v); err != nil {
+		t.Fatalf("error encoding stat file: %s", err)
+	}
+}
+
+func TestLoadOldVersion(t *testing.T) {
+	saveFile := filepath.Join(t.TempDir(), "user-stats")
+	now := time.Now()
+	old := userStatsV010{Username: "dawg", Version: "0.1.0", Created: now, Updated: now}
+	old.Summaries = []summaryV010{{Date: now, Mode: morse.Qcode, AvgPerc: 0.75, AvgDur: time.Second * 3, AvgTries: 1, Count: 8, Wpm: 13}}
+	writeStatFile(t, saveFile, old)
+
+	u, err := Load(saveFile)
+	if err != nil {
+		t.Fatalf("error loading 0.1.0 stat file: %s", err)
+	}
+	if u.Version != StatVersion {
+		t.Errorf("loaded stats should have been migrated to %s, but are %s", StatVersion, u.Version)
+	}
+	if len(u.Summaries) != 1 || u.Summaries[0].Wpm != 13 || u.Summaries[0].Count != 8 || u.Username != "dawg" {
+		t.Errorf("migrated stats didn't keep the old data: %+v", u)
+	}
+
+	// the original should have been backed up, and the file rewritten
+	bak := saveFile + ".0.1.0.bak"
+	if _, err = os.Stat(bak); err != nil {
+		t.Errorf("backup of the original stat file wasn't made: %s", err)
+	}
+	f, err := os.Open(saveFile)
+	if err != nil {
+		t.Fatalf("error opening rewritten stat file: %s", err)
+	}
+	defer f.Close()
+	sv := new(statVersion)
+	if err = gob.NewDecoder(f).Decode(sv); err != nil {
+		t.Fatalf("error decoding rewritten stat file: %s", err)
+	}
+	if sv.Version != StatVersion {
+		t.Errorf("rewritten stat file should have been version %s, got %s", StatVersion, sv.Version)
+	}
+
+	// loading it again shouldn't make another backup
+	if _, err = Load(saveFile); err != nil {
+		t.Errorf("error loading migrated stat file: %s", err)
+	}
+	baks, _ := filepath.Glob(saveFile + ".*.bak")
+	if len(baks) != 1 {
+		t.Errorf("should have had exactly one backup, got %v", baks)
+	}
+}
+
+func TestLoadNewerVersion(t *testing.T) {
+	saveFile := filepath.Join(t.TempDir(), "user-stats")
+	u := New()
+	u.Version = "99.0.0"
+	writeStatFile(t, saveFile, u)
+
+	_, err := Load(saveFile)
+	var nve *NewerVersionError
+	if !errors.As(err, &nve) {
+		t.Fatalf("loading a stat file from a newer version should have returned a NewerVersionError, got %v", err)
+	}
+	if nve.Version != "99.0.0" {
+		t.Errorf("error should have reported version 99.0.0, got %s", nve.Version)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a string
+		b string
+		exp int
+	}{
+		{"0.1.0", "0.2.0", -1},
+		{"0.2.0", "0.2.0", 0},
+		{"0.10.0", "0.2.0", 1},
+		{"1.0.0", "0.9.9", 1},
+	}
+	for _, v := range tests {
+		c, err := compareVersions(v.a, v.b)
+		if err != nil {
+			t.Errorf("error comparing %s and %s: %s", v.a, v.b, err)
+		}
+		if c != v.exp {
+			t.Errorf("comparing %s and %s should have given %d, got %d", v.a, v.b, v.exp, c)
+		}
+	}
+	if _, err := compareVersions("bork", "0.2.0"); err == nil {
+		t.Errorf("comparing an invalid version should have failed")
+	}
+}
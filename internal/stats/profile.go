@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Profiles let several people share one computer, each with their own history
+// and settings. They all live in the same data directory. The default profile
+// is the stats file that's always been there, and named profiles each get a
+// directory of their own under "profiles".
+
+// DefaultProfile is the name of the profile that's used when no other one is
+// given.
+const DefaultProfile = "default"
+
+const (
+	profileDir = "profiles"
+	statFileName = "user-stats"
+)
+
+var validProfile = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+var ErrNoProfile = errors.New("no such profile")
+var ErrProfileExists = errors.New("profile already exists")
+
+// Settings are the practice settings saved with a profile, used when they
+// aren't given on the command line. Zero values mean "not set".
+type Settings struct {
+	Wpm int
+	Farnsworth int
+	Frequency int
+	Mode string
+	TopWordNum int
+}
+
+// ProfilePath returns the path to the stats file for the named profile.
+func ProfilePath(name string) (string, error) {
+	if name == "" || name == DefaultProfile {
+		return filepath.Join(defaultStatDir(), statFileName), nil
+	}
+	if !validProfile.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name '%s': names can only have letters, numbers, '.', '_', and '-' in them, and must start with a letter or number", name)
+	}
+	return filepath.Join(defaultStatDir(), profileDir, name, statFileName), nil
+}
+
+// ListProfiles returns the names of all the profiles, starting with the
+// default profile.
+func ListProfiles() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(defaultStatDir(), profileDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() && validProfile.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return append([]string{DefaultProfile}, names...), nil
+}
+
+// LoadProfile loads the stats for the named profile. Named profiles need to be
+// created with CreateProfile first.
+func LoadProfile(name string) (*UserStats, error) {
+	p, err := ProfilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" && name != DefaultProfile {
+		if _, err = os.Stat(p); os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w '%s'", ErrNoProfile, name)
+		}
+	}
+	return Load(p)
+}
+
+// CreateProfile makes a new named profile with the given settings.
+func CreateProfile(name string, settings Settings) (*UserStats, error) {
+	if name == DefaultProfile {
+		return nil, fmt.Errorf("%w: '%s'", ErrProfileExists, name)
+	}
+	p, err := ProfilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = os.Stat(p); err == nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrProfileExists, name)
+	}
+	if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+
+	u := New()
+	u.Username = name
+	u.SetSettings(settings)
+	if err = u.Save(p); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// DeleteProfile removes a named profile along with all of its history. The
+// default profile can't be deleted.
+func DeleteProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		return errors.New("the default profile can't be deleted")
+	}
+	p, err := ProfilePath(name)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(p)
+	if _, err = os.Stat(dir); os.IsNotExist(err) {
+		return fmt.Errorf("%w '%s'", ErrNoProfile, name)
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"errors"
+	"github.com/ctdk/morseudar/internal/morse"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProfilePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	def, err := ProfilePath(DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def != filepath.Join(defaultStatDir(), "user-stats") {
+		t.Errorf("default profile should use the usual stat file, got %s", def)
+	}
+
+	p, err := ProfilePath("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != filepath.Join(defaultStatDir(), "profiles", "alice", "user-stats") {
+		t.Errorf("unexpected path for profile 'alice': %s", p)
+	}
+
+	for _, bad := range []string{"../alice", "a/b", ".hidden", "-dash", "sp ace"} {
+		if _, err := ProfilePath(bad); err == nil {
+			t.Errorf("profile name '%s' should have been rejected", bad)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := LoadProfile("alice"); !errors.Is(err, ErrNoProfile) {
+		t.Errorf("loading a missing profile should have returned ErrNoProfile, got %v", err)
+	}
+
+	settings := Settings{Wpm: 15, Farnsworth: 8, Mode: "codegroups"}
+	for _, n := range []string{"bob", "alice"} {
+		if _, err := CreateProfile(n, settings); err != nil {
+			t.Fatalf("error creating profile '%s': %s", n, err)
+		}
+	}
+	if _, err := CreateProfile("alice", settings); !errors.Is(err, ErrProfileExists) {
+		t.Errorf("creating 'alice' twice should have returned ErrProfileExists, got %v", err)
+	}
+	if _, err := CreateProfile(DefaultProfile, settings); !errors.Is(err, ErrProfileExists) {
+		t.Errorf("creating the default profile should have returned ErrProfileExists, got %v", err)
+	}
+
+	names, err := ListProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{DefaultProfile, "alice", "bob"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected profiles %v, got %v", want, names)
+	}
+
+	u, err := LoadProfile("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Username != "alice" || u.Settings != settings {
+		t.Errorf("profile 'alice' didn't load as saved: %+v", u)
+	}
+
+	// history is kept separately for each profile
+	u.Add(NewSummary(time.Now(), morse.CodeGroup, 0.9, time.Second, 1, 5, 15, 8))
+	if err = u.Save(u.SavePath()); err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadProfile("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Summaries) != 0 {
+		t.Errorf("profile 'bob' shouldn't have any of alice's sessions, has %d", len(b.Summaries))
+	}
+
+	if err = DeleteProfile("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err = DeleteProfile("alice"); !errors.Is(err, ErrNoProfile) {
+		t.Errorf("deleting 'alice' twice should have returned ErrNoProfile, got %v", err)
+	}
+	if err = DeleteProfile(DefaultProfile); err == nil {
+		t.Error("deleting the default profile should have failed")
+	}
+	names, _ = ListProfiles()
+	if want := []string{DefaultProfile, "bob"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected profiles %v after deleting alice, got %v", want, names)
+	}
+}
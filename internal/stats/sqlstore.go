@@ -0,0 +1,427 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stats
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/ctdk/morseudar/internal/morse"
+	"time"
+)
+
+// SQLStore keeps the stats in a SQLite database. Loading leaves the answers
+// in the database until something asks for them, saving only writes the
+// sessions added since the stats were loaded, rather than the whole history,
+// and questions like which characters have been giving you trouble lately
+// are answered by the database instead of going through every answer. The
+// database driver is the pure Go modernc.org/sqlite, so it doesn't need cgo.
+type SQLStore struct {
+	Path string
+	db *sql.DB
+	// the database ids of the loaded sessions, by where they are in the
+	// stats' summaries
+	ids map[int64]int
+	answersLoaded bool
+	// how many of the stats' summaries are already in the database
+	saved int
+}
+
+const sqlDriver = "sqlite"
+
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS profile (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		username TEXT NOT NULL,
+		version TEXT NOT NULL,
+		created INTEGER NOT NULL,
+		updated INTEGER NOT NULL,
+		wpm INTEGER NOT NULL,
+		farnsworth INTEGER NOT NULL,
+		frequency INTEGER NOT NULL,
+		mode TEXT NOT NULL,
+		top_word_num INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS summaries (
+		id INTEGER PRIMARY KEY,
+		date INTEGER NOT NULL,
+		mode INTEGER NOT NULL,
+		avg_perc REAL NOT NULL,
+		avg_dur INTEGER NOT NULL,
+		avg_tries REAL NOT NULL,
+		count INTEGER NOT NULL,
+		wpm INTEGER NOT NULL,
+		farnsworth INTEGER NOT NULL,
+		duration INTEGER NOT NULL,
+		UNIQUE (date, mode)
+	)`,
+	`CREATE TABLE IF NOT EXISTS answers (
+		id INTEGER PRIMARY KEY,
+		summary_id INTEGER NOT NULL REFERENCES summaries (id) ON DELETE CASCADE,
+		seq INTEGER NOT NULL,
+		date INTEGER NOT NULL,
+		original TEXT NOT NULL,
+		response TEXT NOT NULL,
+		percentage REAL NOT NULL,
+		took INTEGER NOT NULL,
+		tries INTEGER NOT NULL,
+		wpm INTEGER NOT NULL,
+		farnsworth INTEGER NOT NULL,
+		frequency REAL NOT NULL,
+		hinted INTEGER NOT NULL,
+		skipped INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS answers_summary ON answers (summary_id, seq)`,
+	`CREATE INDEX IF NOT EXISTS answers_date ON answers (date)`,
+	// How each character of each answer was copied, worked out when the
+	// answer's saved so the database can do the adding up.
+	`CREATE TABLE IF NOT EXISTS answer_chars (
+		answer_id INTEGER NOT NULL REFERENCES answers (id) ON DELETE CASCADE,
+		char TEXT NOT NULL,
+		copied INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS answer_chars_answer ON answer_chars (answer_id)`,
+	`CREATE TABLE IF NOT EXISTS goals (
+		seq INTEGER PRIMARY KEY,
+		kind INTEGER NOT NULL,
+		period INTEGER NOT NULL,
+		amount INTEGER NOT NULL,
+		accuracy REAL NOT NULL,
+		deadline INTEGER NOT NULL,
+		created INTEGER NOT NULL
+	)`,
+}
+
+// OpenSQLStore opens the SQLite database at the path, creating it if needed.
+func OpenSQLStore(path string) (*SQLStore, error) {
+	db, err := sql.Open(sqlDriver, path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite only lets one writer in at a time anyway, and this way the
+	// pragmas stick.
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range append([]string{"PRAGMA foreign_keys = ON", "PRAGMA busy_timeout = 10000"}, sqlSchema...) {
+		if _, err = db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("setting up stats database %s: %w", path, err)
+		}
+	}
+
+	return &SQLStore{Path: path, db: db}, nil
+}
+
+func (s *SQLStore) Load() (*UserStats, error) {
+	u := New()
+	u.saveFilePath = s.Path
+	u.store = s
+
+	s.ids = make(map[int64]int)
+	s.answersLoaded = false
+	s.saved = 0
+
+	var created, updated int64
+	var version string
+	row := s.db.QueryRow(`SELECT username, version, created, updated, wpm, farnsworth, frequency, mode, top_word_num FROM profile WHERE id = 1`)
+	err := row.Scan(&u.Username, &version, &created, &updated, &u.Settings.Wpm, &u.Settings.Farnsworth, &u.Settings.Frequency, &u.Settings.Mode, &u.Settings.TopWordNum)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// nothing saved yet
+		s.answersLoaded = true
+		return u, nil
+	case err != nil:
+		return nil, err
+	}
+	// an older morseudar can't know what a newer one's put in here
+	cmp, err := compareVersions(version, StatVersion)
+	if err != nil {
+		return nil, err
+	}
+	if cmp > 0 {
+		return nil, &NewerVersionError{Version: version}
+	}
+	u.Created = time.Unix(0, created)
+	u.Updated = time.Unix(0, updated)
+
+	rows, err := s.db.Query(`SELECT id, date, mode, avg_perc, avg_dur, avg_tries, count, wpm, farnsworth, duration FROM summaries ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, date int64
+		var mode int
+		var sum Summary
+		if err = rows.Scan(&id, &date, &mode, &sum.AvgPerc, &sum.AvgDur, &sum.AvgTries, &sum.Count, &sum.Wpm, &sum.Farnsworth, &sum.Duration); err != nil {
+			return nil, err
+		}
+		sum.Date = time.Unix(0, date)
+		sum.Mode = morse.MorseMode(mode)
+		s.ids[id] = len(u.Summaries)
+		u.Summaries = append(u.Summaries, sum)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if u.Goals, err = loadGoals(s.db); err != nil {
+		return nil, err
+	}
+	s.saved = len(u.Summaries)
+
+	return u, nil
+}
+
+// LoadAnswers fills in the answers of the sessions loaded from the database,
+// which are left out when loading since there can be an awful lot of them.
+func (s *SQLStore) LoadAnswers(u *UserStats) error {
+	if s.answersLoaded {
+		return nil
+	}
+
+	rows, err := s.db.Query(`SELECT summary_id, date, original, response, percentage, took, tries, wpm, farnsworth, frequency, hinted, skipped FROM answers ORDER BY summary_id, seq`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sid, date int64
+		var a Answer
+		if err = rows.Scan(&sid, &date, &a.Original, &a.Response, &a.Percentage, &a.Took, &a.Tries, &a.Wpm, &a.Farnsworth, &a.Frequency, &a.Hinted, &a.Skipped); err != nil {
+			return err
+		}
+		a.Date = time.Unix(0, date)
+		if i, ok := s.ids[sid]; ok && i < len(u.Summaries) {
+			u.Summaries[i].Answers = append(u.Summaries[i].Answers, a)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	s.answersLoaded = true
+	return nil
+}
+
+func (s *SQLStore) Save(u *UserStats) error {
+	return s.write(u, false)
+}
+
+func (s *SQLStore) Replace(u *UserStats) error {
+	return s.write(u, true)
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// write saves the profile and any sessions not in the database yet. Sessions
+// already there are left alone, unless everything's being replaced.
+func (s *SQLStore) write(u *UserStats, replace bool) error {
+	// everything's about to be written again, answers and all
+	if replace && u.store == s {
+		if err := s.LoadAnswers(u); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		for _, stmt := range []string{`DELETE FROM answer_chars`, `DELETE FROM answers`, `DELETE FROM summaries`} {
+			if _, err = tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+	}
+
+	// keep the earliest creation time if the database has one, and the
+	// saved settings unless they were changed here
+	settingCols := ""
+	if replace || u.settingsChanged {
+		settingCols = ", wpm = excluded.wpm, farnsworth = excluded.farnsworth, frequency = excluded.frequency, mode = excluded.mode, top_word_num = excluded.top_word_num"
+	}
+	_, err = tx.Exec(`INSERT INTO profile (id, username, version, created, updated, wpm, farnsworth, frequency, mode, top_word_num)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET username = excluded.username, version = excluded.version, created = min(created, excluded.created), updated = max(updated, excluded.updated)`+settingCols,
+		u.Username, StatVersion, u.Created.UnixNano(), u.Updated.UnixNano(), u.Settings.Wpm, u.Settings.Farnsworth, u.Settings.Frequency, u.Settings.Mode, u.Settings.TopWordNum)
+	if err != nil {
+		return err
+	}
+	var set Settings
+	row := tx.QueryRow(`SELECT wpm, farnsworth, frequency, mode, top_word_num FROM profile WHERE id = 1`)
+	if err = row.Scan(&set.Wpm, &set.Farnsworth, &set.Frequency, &set.Mode, &set.TopWordNum); err != nil {
+		return err
+	}
+
+	// Goals go with the profile rather than the history, so they're
+	// replaced wholesale if they were changed here, and otherwise left as
+	// they are.
+	var goals []Goal
+	if replace || u.goalsChanged {
+		if _, err = tx.Exec(`DELETE FROM goals`); err != nil {
+			return err
+		}
+		for i, g := range u.Goals {
+			if _, err = tx.Exec(`INSERT INTO goals (seq, kind, period, amount, accuracy, deadline, created) VALUES (?, ?, ?, ?, ?, ?, ?)`, i, int(g.Kind), int(g.Period), g.Amount, g.Accuracy, unixNano(g.Deadline), unixNano(g.Created)); err != nil {
+				return err
+			}
+		}
+		goals = u.Goals
+	} else if goals, err = loadGoals(tx); err != nil {
+		return err
+	}
+
+	sumStmt, err := tx.Prepare(`INSERT INTO summaries (date, mode, avg_perc, avg_dur, avg_tries, count, wpm, farnsworth, duration) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (date, mode) DO NOTHING`)
+	if err != nil {
+		return err
+	}
+	defer sumStmt.Close()
+	ansStmt, err := tx.Prepare(`INSERT INTO answers (summary_id, seq, date, original, response, percentage, took, tries, wpm, farnsworth, frequency, hinted, skipped) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer ansStmt.Close()
+	charStmt, err := tx.Prepare(`INSERT INTO answer_chars (answer_id, char, copied) VALUES (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer charStmt.Close()
+
+	sums := u.Summaries
+	if !replace && s.saved <= len(sums) {
+		sums = sums[s.saved:]
+	}
+	for _, sum := range sums {
+		res, err := sumStmt.Exec(sum.Date.UnixNano(), int(sum.Mode), sum.AvgPerc, int64(sum.AvgDur), sum.AvgTries, sum.Count, sum.Wpm, sum.Farnsworth, int64(sum.Duration))
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			// already saved
+			continue
+		}
+		sid, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		for i, a := range sum.Answers {
+			res, err := ansStmt.Exec(sid, i, a.Date.UnixNano(), a.Original, a.Response, a.Percentage, int64(a.Took), a.Tries, a.Wpm, a.Farnsworth, a.Frequency, a.Hinted, a.Skipped)
+			if err != nil {
+				return err
+			}
+			aid, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+			chars, copied := CharResults(a.Original, a.Response)
+			for j, r := range chars {
+				if _, err = charStmt.Exec(aid, string(r), copied[j]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	if replace {
+		// the old ids are gone, but everything's in memory now
+		s.ids = make(map[int64]int)
+		s.answersLoaded = true
+	}
+	s.saved = len(u.Summaries)
+	u.Settings = set
+	u.Goals = goals
+	return nil
+}
+
+// queryer is either the database or a transaction on it.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func loadGoals(q queryer) ([]Goal, error) {
+	rows, err := q.Query(`SELECT kind, period, amount, accuracy, deadline, created FROM goals ORDER BY seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []Goal
+	for rows.Next() {
+		var kind, period int
+		var deadline, created int64
+		var g Goal
+		if err = rows.Scan(&kind, &period, &g.Amount, &g.Accuracy, &deadline, &created); err != nil {
+			return nil, err
+		}
+		g.Kind = GoalKind(kind)
+		g.Period = GoalPeriod(period)
+		g.Deadline = fromUnixNano(deadline)
+		g.Created = fromUnixNano(created)
+		goals = append(goals, g)
+	}
+	return goals, rows.Err()
+}
+
+func (s *SQLStore) CharErrorRates(since time.Time) ([]CharErrorRate, error) {
+	rows, err := s.db.Query(`SELECT c.char, count(*), sum(1 - c.copied) FROM answer_chars c JOIN answers a ON a.id = c.answer_id WHERE a.date >= ? GROUP BY c.char`, since.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cer := make([]CharErrorRate, 0)
+	for rows.Next() {
+		var char string
+		var c CharErrorRate
+		if err = rows.Scan(&char, &c.Sent, &c.Missed); err != nil {
+			return nil, err
+		}
+		c.Char = []rune(char)[0]
+		cer = append(cer, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	sortCharErrorRates(cer)
+	return cer, nil
+}
+
+// Zero times are way out of the range UnixNano can handle, so they're kept as
+// 0.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
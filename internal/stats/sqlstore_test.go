@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"errors"
+	"github.com/ctdk/morseudar/internal/morse"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.db")
+	u, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer u.Close()
+
+	now := time.Now()
+	s := NewSummary(now, morse.TopWords, 0.5, time.Second, 1.5, 2, 15, 10)
+	s.Answers = []Answer{
+		{Date: now, Original: "the cat", Response: "the bat", Percentage: 0.8, Took: time.Second, Tries: 1, Wpm: 15, Frequency: 700, Hinted: true},
+		{Date: now.AddDate(0, 0, -60), Original: "qrm", Response: "qrn"},
+	}
+	s.Duration = time.Minute
+	u.Add(s)
+	u.SetSettings(Settings{Mode: "topwords"})
+	daily, _ := ParseGoal("15 minutes a day", now)
+	speed, _ := ParseGoal("20 wpm by 2200-01-01", now)
+	u.AddGoal(daily)
+	u.AddGoal(speed)
+	// saving twice shouldn't save the session twice
+	for i := 0; i < 2; i++ {
+		if err = u.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// the answers are left until they're asked for
+	if len(l.Summaries) != 1 || len(l.Summaries[0].Answers) != 0 {
+		t.Fatalf("expected 1 session without its answers, got %+v", l.Summaries)
+	}
+	for i := 0; i < 2; i++ {
+		if err = l.LoadAnswers(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(l.Summaries[0].Answers) != 2 {
+		t.Fatalf("expected the session to have 2 answers once they were loaded, got %+v", l.Summaries[0].Answers)
+	}
+	if l.Settings.Mode != "topwords" {
+		t.Errorf("settings weren't saved: %+v", l.Settings)
+	}
+	if l.Summaries[0].Duration != time.Minute {
+		t.Errorf("session duration wasn't saved: %s", l.Summaries[0].Duration)
+	}
+	if len(l.Goals) != 2 || !l.Goals[0].Deadline.IsZero() || !l.Goals[1].Deadline.Equal(speed.Deadline) {
+		t.Errorf("goals weren't saved: %+v", l.Goals)
+	}
+	a := l.Summaries[0].Answers[0]
+	if a.Original != "the cat" || !a.Hinted || a.Took != time.Second || !a.Date.Equal(now) {
+		t.Errorf("answer didn't load as saved: %+v", a)
+	}
+
+	// the database should come up with the same thing as going through
+	// the answers in memory
+	since := now.AddDate(0, 0, -30)
+	rates, err := l.CharErrorRates(since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem := &UserStats{Summaries: l.Summaries}
+	memRates, _ := mem.CharErrorRates(since)
+	if len(rates) != len(memRates) {
+		t.Fatalf("database and in memory rates differ: %v, %v", rates, memRates)
+	}
+	for i := range rates {
+		if rates[i] != memRates[i] {
+			t.Errorf("database and in memory rates differ: %v, %v", rates, memRates)
+		}
+	}
+
+	l.Summaries = nil
+	if err = l.Replace(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Summaries) != 0 {
+		t.Errorf("Replace should have left no sessions, got %d", len(r.Summaries))
+	}
+}
+
+// Saving only writes the sessions added since loading, rather than everything
+// loaded along with them.
+func TestSQLStoreSavesNewSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.db")
+	now := time.Now()
+	u, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer u.Close()
+	u.Add(NewSummary(now, morse.TopWords, 0.5, time.Second, 1, 2, 15, 0))
+	if err = u.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// somebody else clears the history out in the meantime
+	u.Summaries = nil
+	if err = u.Replace(); err != nil {
+		t.Fatal(err)
+	}
+	l.Add(NewSummary(now.Add(time.Minute), morse.CodeGroup, 0.9, time.Second, 1, 2, 15, 0))
+	if err = l.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Summaries) != 1 || r.Summaries[0].Mode != morse.CodeGroup {
+		t.Errorf("only the new session should have been saved, got %+v", r.Summaries)
+	}
+}
+
+func TestSQLStoreNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.db")
+	u, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer u.Close()
+	u.Add(NewSummary(time.Now(), morse.TopWords, 0.5, time.Second, 1, 2, 15, 0))
+	if err = u.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = u.store.(*SQLStore).db.Exec(`UPDATE profile SET version = '99.0.0'`); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Load(path)
+	var nve *NewerVersionError
+	if !errors.As(err, &nve) || nve.Version != "99.0.0" {
+		t.Errorf("loading a database from a newer version should have failed with a NewerVersionError, got %v", err)
+	}
+}
@@ -17,8 +17,8 @@
 package stats
 
 import (
-	"encoding/gob"
 	"fmt"
+	"github.com/ctdk/morseudar/internal/copy-compare"
 	"github.com/ctdk/morseudar/internal/morse"
 	"os"
 	"os/user"
@@ -27,7 +27,7 @@ import (
 	"time"
 )
 
-const StatVersion = "0.1.0"
+const StatVersion = "0.4.0"
 
 // A bit empty at the moment, but ought to become more detailed at a later time
 // I think.
@@ -37,7 +37,15 @@ type UserStats struct {
 	Version string
 	Created time.Time
 	Updated time.Time
+	Settings Settings
+	Goals []Goal
 	saveFilePath string
+	store Store
+	// Whether the settings or goals were changed since these stats were
+	// loaded. If they weren't, saving keeps whatever's saved already, in
+	// case they were changed somewhere else in the meantime.
+	settingsChanged bool
+	goalsChanged bool
 }
 
 type Summary struct {
@@ -49,6 +57,30 @@ type Summary struct {
 	Count int
 	Wpm int
 	Farnsworth int
+	Answers []Answer
+	// how long the session went on for
+	Duration time.Duration
+}
+
+// Answer is the record of a single answer in a session. Sessions saved before
+// stats version 0.2.0 don't have these.
+type Answer struct {
+	Date time.Time
+	Original string
+	Response string
+	Percentage float64
+	Took time.Duration
+	Tries int
+	Wpm int
+	Farnsworth int
+	Frequency float64
+	Hinted bool
+	Skipped bool
+}
+
+// NewAnswer makes a stats record out of an answer from a session.
+func NewAnswer(ans compare.Answer) Answer {
+	return Answer{Date: ans.Date, Original: ans.Original, Response: ans.Response, Percentage: ans.Percentage, Took: ans.Took, Tries: ans.Tries, Wpm: ans.Wpm, Farnsworth: ans.Farnsworth, Frequency: ans.Frequency, Hinted: ans.Hinted, Skipped: ans.Skipped}
 }
 
 func NewSummary(date time.Time, mode morse.MorseMode, perc float64, dur time.Duration, tries float64, count int, wpm int, farns int) Summary {
@@ -56,10 +88,28 @@ func NewSummary(date time.Time, mode morse.MorseMode, perc float64, dur time.Dur
 }
 
 func (s Summary) String() string {
-	str := fmt.Sprintf("- Date: %s\tMode: %s\tAvg %% Correct: %.2f%%\t Avg Dur: %s\tAvg Tries: %.2f\t WPM: %d\tFarnsworth: %d", s.Date, s.Mode, s.AvgPerc * 100, s.AvgDur.Round(time.Second / 100), s.AvgTries, s.Wpm, s.Farnsworth)
+	wpm := fmt.Sprintf("%d", s.Wpm)
+	if lo, hi := s.WpmRange(); lo != hi {
+		wpm = fmt.Sprintf("%d-%d", lo, hi)
+	}
+	str := fmt.Sprintf("- Date: %s\tMode: %s\tAvg %% Correct: %.2f%%\t Avg Dur: %s\tAvg Tries: %.2f\t WPM: %s\tFarnsworth: %d", s.Date, s.Mode, s.AvgPerc * 100, s.AvgDur.Round(time.Second / 100), s.AvgTries, wpm, s.Farnsworth)
 	return str
 }
 
+// WpmRange returns the slowest and fastest speeds answers in this session were
+// sent at. Older sessions without per-answer history just have the one.
+func (s Summary) WpmRange() (int, int) {
+	if len(s.Answers) == 0 {
+		return s.Wpm, s.Wpm
+	}
+	lo, hi := s.Answers[0].Wpm, s.Answers[0].Wpm
+	for _, sp := range s.Answers[1:] {
+		lo = min(lo, sp.Wpm)
+		hi = max(hi, sp.Wpm)
+	}
+	return lo, hi
+}
+
 func New() *UserStats {
 	t := time.Now()
 	u := new(UserStats)
@@ -79,62 +129,175 @@ func (u *UserStats) Add(s Summary) {
 	return
 }
 
+// Load loads the user's stats from the given file, or the default stat file if
+// there isn't one. Stat files ending in .db, .sqlite, or .sqlite3 are SQLite
+// databases, and anything else is a gob file.
 func Load(s ...string) (*UserStats, error) {
 	var saveFile string
 	if len(s) > 0 && s[0] != "" {
 		saveFile = s[0]
 	} else {
 		baseDir := defaultStatDir()
-		saveFile = filepath.Join(baseDir, "user-stats")
+		saveFile = filepath.Join(baseDir, statFileName)
 	}
 
-	fp, err := os.Open(saveFile)
-	if err != nil {
-		// If the file doesn't exist, it just means the data's never
-		// been saved. Create a new UserStats object and send it back.
-		if os.IsNotExist(err) {
-			uNew := New()
-			uNew.saveFilePath = saveFile
-			return uNew, nil
-		}
-		return nil, err
-	}
-	u := new(UserStats)
-	dec := gob.NewDecoder(fp)
-	err = dec.Decode(&u)
+	st, err := OpenStore(saveFile)
 	if err != nil {
-		fp.Close()
 		return nil, err
 	}
-	u.saveFilePath = saveFile
-	return u, fp.Close()
+	return st.Load()
 }
 
+// Save writes the stats out, first merging in any sessions that were saved by
+// someone else since these stats were loaded.
 func (u *UserStats) Save(s ...string) error {
-	if len(s) > 0 && s[0] != "" {
-		u.saveFilePath = s[0]
+	st, err := u.storeFor(s...)
+	if err != nil {
+		return err
 	}
-	if err := os.Mkdir(filepath.Dir(u.saveFilePath), 0755); err != nil && !os.IsExist(err) {
+	if err = st.Save(u); err != nil {
 		return err
 	}
+	u.settingsChanged, u.goalsChanged = false, false
+	return nil
+}
 
-	fp, err := os.CreateTemp(filepath.Dir(u.saveFilePath), "user-stats")
+// Replace writes the stats out over whatever's already saved, without merging
+// anything in.
+func (u *UserStats) Replace(s ...string) error {
+	st, err := u.storeFor(s...)
 	if err != nil {
 		return err
 	}
+	if err = st.Replace(u); err != nil {
+		return err
+	}
+	u.settingsChanged, u.goalsChanged = false, false
+	return nil
+}
+
+// LoadAnswers makes sure the sessions have their answers, for anything that
+// needs more than the averages. Stats kept in a database are loaded without
+// them, since there can be a lot.
+func (u *UserStats) LoadAnswers() error {
+	if al, ok := u.store.(AnswerLoader); ok {
+		return al.LoadAnswers(u)
+	}
+	return nil
+}
+
+// Close closes wherever the stats are kept, if that needs closing.
+func (u *UserStats) Close() error {
+	if u.store == nil {
+		return nil
+	}
+	err := u.store.Close()
+	u.store = nil
+	return err
+}
+
+// storeFor returns the store to save to, opening a new one if the stats are
+// being saved somewhere else.
+func (u *UserStats) storeFor(s ...string) (Store, error) {
+	if len(s) > 0 && s[0] != "" && s[0] != u.saveFilePath {
+		// the new place doesn't have any of these yet, so all of them
+		// need to be there to be written out
+		if err := u.LoadAnswers(); err != nil {
+			return nil, err
+		}
+		u.Close()
+		u.saveFilePath = s[0]
+	}
+	if u.store == nil {
+		st, err := OpenStore(u.saveFilePath)
+		if err != nil {
+			return nil, err
+		}
+		u.store = st
+	}
+	return u.store, nil
+}
 
-	enc := gob.NewEncoder(fp)
-	err = enc.Encode(u)
+// mergeSaved reads in the stats currently saved in the file and merges them
+// with these, keeping the saved sessions in front. The saved settings and goals
+// are kept unless they've been changed here.
+func (u *UserStats) mergeSaved(saveFile string) error {
+	raw, err := os.ReadFile(saveFile)
 	if err != nil {
-		fp.Close()
+		if os.IsNotExist(err) {
+			return nil
+		}
 		return err
 	}
+	if len(raw) == 0 {
+		return nil
+	}
+	saved, _, err := decodeStats(raw)
+	if err != nil {
+		return fmt.Errorf("reading %s to merge with before saving: %w", saveFile, err)
+	}
 
-	if err = fp.Close(); err != nil {
-		return err
+	saved.Merge(u)
+	u.Summaries = saved.Summaries
+	u.Created = saved.Created
+	u.Updated = saved.Updated
+	if !u.settingsChanged {
+		u.Settings = saved.Settings
+	}
+	if !u.goalsChanged {
+		u.Goals = saved.Goals
+	}
+	return nil
+}
+
+// SetSettings changes the practice settings saved with the stats.
+func (u *UserStats) SetSettings(s Settings) {
+	u.Settings = s
+	u.settingsChanged = true
+	u.Updated = time.Now()
+}
+
+// Merge adds the sessions from other that aren't already in these stats. Two
+// sessions are the same if they started at the same time in the same mode.
+// The earliest creation time and the latest update time are kept.
+func (u *UserStats) Merge(other *UserStats) {
+	seen := make(map[summaryKey]bool, len(u.Summaries))
+	for _, s := range u.Summaries {
+		seen[s.key()] = true
+	}
+	for _, s := range other.Summaries {
+		if k := s.key(); !seen[k] {
+			seen[k] = true
+			u.Summaries = append(u.Summaries, s)
+		}
+	}
+
+	if !other.Created.IsZero() && (u.Created.IsZero() || other.Created.Before(u.Created)) {
+		u.Created = other.Created
 	}
+	if other.Updated.After(u.Updated) {
+		u.Updated = other.Updated
+	}
+}
+
+type summaryKey struct {
+	date int64
+	mode morse.MorseMode
+}
+
+func (s Summary) key() summaryKey {
+	return summaryKey{s.Date.UnixNano(), s.Mode}
+}
+
+// SavePath returns the path of the file these stats are saved to.
+func (u *UserStats) SavePath() string {
+	return u.saveFilePath
+}
 
-	return os.Rename(fp.Name(), u.saveFilePath)
+// DataDir is the directory where morseudar keeps the stats file, profiles,
+// and the config file.
+func DataDir() string {
+	return defaultStatDir()
 }
 
 func defaultStatDir() string {
@@ -17,41 +17,32 @@
 package stats
 
 import (
+	"github.com/ctdk/morseudar/internal/copy-compare"
 	"github.com/ctdk/morseudar/internal/morse"
-	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
 
 func TestSaveStatFile(t *testing.T) {
-	f, err := os.CreateTemp("", "stat-test")
-	if err != nil {
-		t.Errorf("error creating test stat file: %s", err)
-	}
-	f.Close()
-	defer os.Remove(f.Name())
+	saveFile := filepath.Join(t.TempDir(), "user-stats")
 	u := New()
-	err = u.Save(f.Name())
+	err := u.Save(saveFile)
 	if err != nil {
 		t.Errorf("error saving file: %s", err)
 	}
 }
 
 func TestLoadStatFile(t *testing.T) {
-	f, err := os.CreateTemp("", "stat-test")
-	if err != nil {
-		t.Errorf("error creating test stat file: %s", err)
-	}
-	f.Close()
-	defer os.Remove(f.Name())
+	saveFile := filepath.Join(t.TempDir(), "user-stats")
 	u := New()
 	origTime := u.Created
-	err = u.Save(f.Name())
+	err := u.Save(saveFile)
 	if err != nil {
 		t.Errorf("error saving file: %s", err)
 	}
 
-	u2, err := Load(f.Name())
+	u2, err := Load(saveFile)
 	if err != nil {
 		t.Errorf("error loading stat file: %s", err)
 	}
@@ -62,12 +53,7 @@ func TestLoadStatFile(t *testing.T) {
 }
 
 func TestSummaries(t *testing.T) {
-	f, err := os.CreateTemp("", "stat-test")
-	if err != nil {
-		t.Errorf("error creating test stat file: %s", err)
-	}
-	f.Close()
-	defer os.Remove(f.Name())
+	saveFile := filepath.Join(t.TempDir(), "user-stats")
 	u := New()
 
 	s1 := NewSummary(time.Now(), morse.TopWords, 76.4, time.Minute * 15, 2.4, 28, 10, 0)
@@ -87,12 +73,12 @@ func TestSummaries(t *testing.T) {
 		t.Errorf("user stats failed to update the updated time")
 	}
 
-	err = u.Save(f.Name())
+	err := u.Save(saveFile)
 	if err != nil {
 		t.Errorf("error saving file: %s", err)
 	}
 
-	u2, err := Load(f.Name())
+	u2, err := Load(saveFile)
 	if err != nil {
 		t.Errorf("error loading stat file: %s", err)
 	}
@@ -110,3 +96,43 @@ func TestSummaries(t *testing.T) {
 		t.Errorf("s1 date not equal to u2.Summaries[0] date loaded from disk")
 	}
 }
+
+func TestSummaryAnswers(t *testing.T) {
+	saveFile := filepath.Join(t.TempDir(), "user-stats")
+
+	s := NewSummary(time.Now(), morse.TopWords, 0.9, time.Second * 4, 1, 3, 12, 0)
+	if lo, hi := s.WpmRange(); lo != 12 || hi != 12 {
+		t.Errorf("a summary without per-answer history should have a range of 12-12, got %d-%d", lo, hi)
+	}
+
+	answers := compare.AnswerBatch{
+		{Date: time.Now(), Original: "foo", Response: "foo", Percentage: 1, Took: time.Second, Tries: 1, Wpm: 12},
+		{Date: time.Now(), Original: "bar", Response: "baz", Percentage: 0.666667, Took: time.Second * 3, Tries: 2, Wpm: 14, Hinted: true},
+		{Date: time.Now(), Original: "qux", Percentage: 0, Took: time.Second, Tries: 1, Wpm: 11, Farnsworth: 8, Skipped: true},
+	}
+	for _, ans := range answers {
+		s.Answers = append(s.Answers, NewAnswer(ans))
+	}
+	if lo, hi := s.WpmRange(); lo != 11 || hi != 14 {
+		t.Errorf("speed range should have been 11-14, got %d-%d", lo, hi)
+	}
+
+	u := New()
+	u.Add(s)
+	if err := u.Save(saveFile); err != nil {
+		t.Errorf("error saving file: %s", err)
+	}
+	u2, err := Load(saveFile)
+	if err != nil {
+		t.Fatalf("error loading stat file: %s", err)
+	}
+	loaded := u2.Summaries[0].Answers
+	if len(loaded) != len(answers) {
+		t.Fatalf("should have loaded %d answers, got %d", len(answers), len(loaded))
+	}
+	for i, ans := range answers {
+		if loaded[i].Original != ans.Original || loaded[i].Response != ans.Response || loaded[i].Tries != ans.Tries || loaded[i].Hinted != ans.Hinted || loaded[i].Skipped != ans.Skipped || !loaded[i].Date.Equal(ans.Date) {
+			t.Errorf("answer %d didn't survive the trip to disk: saved %+v, loaded %+v", i, ans, loaded[i])
+		}
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stats
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Store is somewhere stats are kept. Load and Save on UserStats go through
+// whichever store the stat file calls for.
+type Store interface {
+	// Load loads the stats, or returns new ones if nothing's been saved
+	// yet.
+	Load() (*UserStats, error)
+	// Save saves the stats, keeping any sessions already saved that these
+	// stats don't have.
+	Save(u *UserStats) error
+	// Replace saves the stats over whatever's already saved.
+	Replace(u *UserStats) error
+	Close() error
+}
+
+// Querier is a store that can work things out from the stats itself, without
+// having to go through every session in memory.
+type Querier interface {
+	// CharErrorRates works out how often each character was missed in
+	// the answers given since the given time, worst first.
+	CharErrorRates(since time.Time) ([]CharErrorRate, error)
+}
+
+// AnswerLoader is a store that leaves the sessions' answers out when loading,
+// and only loads them when they're asked for.
+type AnswerLoader interface {
+	// LoadAnswers fills in the answers of the sessions that were loaded
+	// without them.
+	LoadAnswers(u *UserStats) error
+}
+
+// OpenStore opens the right kind of store for the stat file.
+func OpenStore(saveFile string) (Store, error) {
+	if IsDatabase(saveFile) {
+		return OpenSQLStore(saveFile)
+	}
+	return &FileStore{Path: saveFile}, nil
+}
+
+// IsDatabase returns true if the stat file's name says it's a SQLite database.
+func IsDatabase(saveFile string) bool {
+	switch strings.ToLower(filepath.Ext(saveFile)) {
+	case ".db", ".sqlite", ".sqlite3":
+		return true
+	}
+	return false
+}
+
+// FileStore keeps the stats in a gob file, the way they've always been kept.
+// The whole file gets read on load and written out again on every save.
+type FileStore struct {
+	Path string
+}
+
+func (f *FileStore) Load() (*UserStats, error) {
+	raw, err := os.ReadFile(f.Path)
+	if err != nil {
+		// If the file doesn't exist, it just means the data's never
+		// been saved. Create a new UserStats object and send it back.
+		if os.IsNotExist(err) {
+			uNew := New()
+			uNew.saveFilePath = f.Path
+			uNew.store = f
+			return uNew, nil
+		}
+		return nil, err
+	}
+
+	u, origVersion, err := decodeStats(raw)
+	if err != nil {
+		return nil, err
+	}
+	u.saveFilePath = f.Path
+	u.store = f
+
+	// If the stats had to be migrated, keep a copy of the original file
+	// around before writing the new version out over it.
+	if origVersion != u.Version {
+		if _, err = backup(f.Path, origVersion, raw); err != nil {
+			return nil, fmt.Errorf("backing up stat file before migrating it: %w", err)
+		}
+		if err = f.Save(u); err != nil {
+			return nil, err
+		}
+	}
+
+	return u, nil
+}
+
+func (f *FileStore) Save(u *UserStats) error {
+	return f.write(u, true)
+}
+
+func (f *FileStore) Replace(u *UserStats) error {
+	return f.write(u, false)
+}
+
+func (f *FileStore) Close() error {
+	return nil
+}
+
+func (f *FileStore) write(u *UserStats, merge bool) error {
+	if err := os.Mkdir(filepath.Dir(f.Path), 0755); err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	lock, err := lockStatFile(f.Path)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	if merge {
+		if err = u.mergeSaved(f.Path); err != nil {
+			return err
+		}
+	}
+
+	fp, err := os.CreateTemp(filepath.Dir(f.Path), "user-stats")
+	if err != nil {
+		return err
+	}
+
+	enc := gob.NewEncoder(fp)
+	err = enc.Encode(u)
+	if err != nil {
+		fp.Close()
+		os.Remove(fp.Name())
+		return err
+	}
+
+	if err = fp.Close(); err != nil {
+		os.Remove(fp.Name())
+		return err
+	}
+
+	return os.Rename(fp.Name(), f.Path)
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stats
+
+import (
+	"github.com/ctdk/morseudar/internal/morse"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenStore(t *testing.T) {
+	dir := t.TempDir()
+	st, err := OpenStore(filepath.Join(dir, "user-stats"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := st.(*FileStore); !ok {
+		t.Errorf("expected a plain stat file to use a FileStore, got %T", st)
+	}
+
+	for _, f := range []string{"stats.db", "stats.SQLite", "stats.sqlite3"} {
+		if !IsDatabase(f) {
+			t.Errorf("%s should have been taken for a database", f)
+		}
+	}
+
+	st, err = OpenStore(filepath.Join(dir, "stats.db"))
+	if err != nil {
+		t.Fatalf("opening a database should have worked, got %v", err)
+	}
+	defer st.Close()
+	if _, ok := st.(*SQLStore); !ok {
+		t.Errorf("expected stats.db to use a SQLStore, got %T", st)
+	}
+}
+
+func TestCharErrorRates(t *testing.T) {
+	now := time.Now()
+	u := New()
+	s := NewSummary(now, morse.TopWords, 0.5, time.Second, 1, 3, 15, 0)
+	s.Answers = []Answer{
+		{Date: now, Original: "the cat", Response: "the bat"},
+		{Date: now, Original: "TEA", Response: "tea"},
+		// too long ago to count
+		{Date: now.AddDate(0, 0, -60), Original: "zzz", Response: "aaa"},
+	}
+	u.Add(s)
+
+	rates, err := u.CharErrorRates(now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 5 {
+		t.Fatalf("expected rates for 5 characters, got %v", rates)
+	}
+	if rates[0].Char != 'c' || rates[0].Sent != 1 || rates[0].Missed != 1 || rates[0].Rate() != 1 {
+		t.Errorf("'c' should have been the worst, missed the one time it was sent, got %+v", rates[0])
+	}
+	if rates[1].Char != 't' || rates[1].Sent != 3 || rates[1].Missed != 0 {
+		t.Errorf("'t' should have been next, sent 3 times and never missed, got %+v", rates[1])
+	}
+	for _, r := range rates {
+		if r.Char == 'z' || r.Char == ' ' {
+			t.Errorf("'%c' shouldn't have been counted", r.Char)
+		}
+	}
+}
@@ -28,6 +28,8 @@ import (
 
 type Textblock struct {
 	lines []morsestrings.MorseString
+	paraStarts []bool
+	srcLines []int
 	numLines int
 	pos int
 	randNum *rand.Rand
@@ -46,7 +48,12 @@ func (tb *Textblock) LoadFile(filepath string) error {
 	}
 	defer f.Close()
 
-	txt, err := io.ReadAll(f)
+	return tb.Load(f)
+}
+
+// Load loads text from r, like standard input, rather than from a file.
+func (tb *Textblock) Load(r io.Reader) error {
+	txt, err := io.ReadAll(r)
 	if err != nil {
 		return err
 	}
@@ -59,11 +66,23 @@ func (tb *Textblock) processTextFile(text string) error {
 	}
 	textLines := strings.Split(text, "\n")
 	tb.lines = make([]morsestrings.MorseString, 0, len(textLines))
-	for _, l := range textLines {
+	tb.paraStarts = make([]bool, 0, len(textLines))
+	tb.srcLines = make([]int, 0, len(textLines))
+
+	// blank lines separate paragraphs, which only matter when sending the
+	// whole block at once. Also keep track of which line of the file each
+	// line came from, since the blank lines get dropped.
+	newPara := true
+	for i, l := range textLines {
 		l = strings.TrimSpace(l)
 		if len(l) > 0 {
 			ml := morsestrings.StringToMorse(l)
 			tb.lines = append(tb.lines, ml)
+			tb.paraStarts = append(tb.paraStarts, newPara)
+			tb.srcLines = append(tb.srcLines, i + 1)
+			newPara = false
+		} else {
+			newPara = true
 		}
 	}
 
@@ -91,6 +110,64 @@ func (tb *Textblock) GetAllLines() ([]morsestrings.MorseString, error) {
 	return tb.lines, nil
 }
 
+// GetParagraphs returns the lines from start up to (but not including) end,
+// grouped by paragraph. Lines are counted from 0, and an end of 0 means "to the
+// end of the text".
+func (tb *Textblock) GetParagraphs(start int, end int) ([][]morsestrings.MorseString, error) {
+	if tb.numLines == 0 {
+		return nil, morserrors.NoText
+	}
+	if end == 0 {
+		end = tb.numLines
+	}
+	if start < 0 || start >= end || end > tb.numLines {
+		return nil, morserrors.OutOfRange
+	}
+
+	paras := make([][]morsestrings.MorseString, 0)
+	for i := start; i < end; i++ {
+		if i == start || tb.paraStarts[i] {
+			paras = append(paras, make([]morsestrings.MorseString, 0))
+		}
+		p := len(paras) - 1
+		paras[p] = append(paras[p], tb.lines[i])
+	}
+
+	return paras, nil
+}
+
+// LineRange converts the lines first through last of the original file
+// (counting from 1, blank lines included) into the start and end arguments
+// GetParagraphs wants. A first or last of 0 means the start or end of the text.
+func (tb *Textblock) LineRange(first int, last int) (int, int, error) {
+	if tb.numLines == 0 {
+		return 0, 0, morserrors.NoText
+	}
+	start := 0
+	for start < tb.numLines && tb.srcLines[start] < first {
+		start++
+	}
+	end := tb.numLines
+	if last > 0 {
+		for end > 0 && tb.srcLines[end - 1] > last {
+			end--
+		}
+	}
+	if start >= end {
+		return 0, 0, morserrors.OutOfRange
+	}
+	return start, end, nil
+}
+
+// SourceLine returns the line of the original file line i came from, counting
+// from 1.
+func (tb *Textblock) SourceLine(i int) int {
+	if i < 0 || i >= tb.numLines {
+		return 0
+	}
+	return tb.srcLines[i]
+}
+
 func (tb *Textblock) NumLines() int {
 	return tb.numLines
 }
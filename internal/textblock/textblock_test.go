@@ -102,3 +102,86 @@ func TestRandomLines(t *testing.T) {
 		}
 	}
 }
+
+func TestGetParagraphs(t *testing.T) {
+	txt := "one two\nthree\n\n\nfour five\n\nsix\nseven"
+	tb := NewTextblock(src)
+	if err := tb.processTextFile(txt); err != nil {
+		t.Fatalf("error processing text: %v", err)
+	}
+
+	paras, err := tb.GetParagraphs(0, 0)
+	if err != nil {
+		t.Fatalf("error getting paragraphs: %v", err)
+	}
+	expectedLens := []int{2, 1, 2}
+	if len(paras) != len(expectedLens) {
+		t.Fatalf("expected %d paragraphs, got %d", len(expectedLens), len(paras))
+	}
+	for i, l := range expectedLens {
+		if len(paras[i]) != l {
+			t.Errorf("paragraph %d should have had %d lines, got %d", i, l, len(paras[i]))
+		}
+	}
+
+	// a range starting in the middle of a paragraph starts a new one
+	paras, err = tb.GetParagraphs(1, 4)
+	if err != nil {
+		t.Fatalf("error getting paragraph range: %v", err)
+	}
+	if len(paras) != 3 || paras[0][0].RawString() != "three" || paras[2][0].RawString() != "six" {
+		t.Errorf("unexpected paragraphs for lines 1-4: %v", paras)
+	}
+
+	if _, err = tb.GetParagraphs(3, 10); err == nil {
+		t.Errorf("getting paragraphs past the end of the text should have failed")
+	}
+}
+
+func TestLineRange(t *testing.T) {
+	// file lines:  1        2      3 4 5          6 7    8
+	txt := "one two\nthree\n\n\nfour five\n\nsix\nseven"
+	tb := NewTextblock(src)
+	if err := tb.processTextFile(txt); err != nil {
+		t.Fatalf("error processing text: %v", err)
+	}
+
+	tests := []struct {
+		first int
+		last int
+		start int
+		end int
+	}{
+		{0, 0, 0, 5},
+		{1, 8, 0, 5},
+		{2, 5, 1, 3},
+		// blank lines at either end are skipped over
+		{3, 6, 2, 3},
+		{6, 0, 3, 5},
+	}
+	for _, tt := range tests {
+		start, end, err := tb.LineRange(tt.first, tt.last)
+		if err != nil {
+			t.Errorf("error getting line range %d-%d: %v", tt.first, tt.last, err)
+			continue
+		}
+		if start != tt.start || end != tt.end {
+			t.Errorf("file lines %d-%d should have been %d-%d, got %d-%d", tt.first, tt.last, tt.start, tt.end, start, end)
+		}
+	}
+
+	// nothing but blank lines
+	if _, _, err := tb.LineRange(3, 4); err == nil {
+		t.Errorf("a range with only blank lines should have failed")
+	}
+	if _, _, err := tb.LineRange(9, 0); err == nil {
+		t.Errorf("a range past the end of the text should have failed")
+	}
+
+	expected := []int{1, 2, 5, 7, 8}
+	for i, e := range expected {
+		if sl := tb.SourceLine(i); sl != e {
+			t.Errorf("line %d should have come from line %d of the file, got %d", i, e, sl)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tui
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/session"
+	"golang.org/x/term"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ANSI escape codes. Nothing fancier than what any terminal from the last
+// few decades can do.
+const (
+	altScreen = "\x1b[?1049h"
+	mainScreen = "\x1b[?1049l"
+	home = "\x1b[H"
+	clearLine = "\x1b[K"
+	clearBelow = "\x1b[J"
+	bold = "\x1b[1m"
+	dim = "\x1b[2m"
+	underline = "\x1b[4m"
+	reverse = "\x1b[7m"
+	red = "\x1b[31m"
+	green = "\x1b[32m"
+	yellow = "\x1b[33m"
+	reset = "\x1b[0m"
+)
+
+const prompt = "Copy > "
+
+// the most lines of a message to show, unless the screen's tiny
+const maxMessage = 14
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// draw draws the whole screen. It's called with the lock held.
+func (t *TUI) draw() {
+	if t.oldState == nil {
+		// not started, or closed
+		return
+	}
+	w, h := t.size()
+	rows, cursorRow, cursorCol := t.screen(time.Now(), w, h)
+
+	var sb strings.Builder
+	sb.WriteString(home)
+	for i, row := range rows {
+		sb.WriteString(row)
+		sb.WriteString(clearLine)
+		if i < len(rows) - 1 {
+			sb.WriteString("\r\n")
+		}
+	}
+	sb.WriteString(clearBelow)
+	fmt.Fprintf(&sb, "\x1b[%d;%dH", cursorRow + 1, cursorCol + 1)
+	fmt.Fprint(t.out, sb.String())
+}
+
+func (t *TUI) size() (int, int) {
+	if outFd, ok := fd(t.out); ok {
+		if w, h, err := term.GetSize(outFd); err == nil && w > 0 && h > 0 {
+			return w, h
+		}
+	}
+	return t.width, t.height
+}
+
+// screen lays out the screen as rows of text, w wide and no more than h high,
+// and says where the cursor goes.
+func (t *TUI) screen(now time.Time, w int, h int) ([]string, int, int) {
+	rows := make([]string, 0, h)
+	rows = append(rows, t.header(now, w), "", t.progress(now, w), "")
+
+	inputRow := len(rows)
+	in, cursorCol := t.inputLine(w)
+	rows = append(rows, in, "")
+
+	body := make([]string, 0, h)
+	msgMax := min(maxMessage, max(1, h / 3))
+	for i, l := range t.message {
+		if i == msgMax - 1 && len(t.message) > msgMax {
+			body = append(body, dim + fit(fmt.Sprintf("(and %d more lines)", len(t.message) - i), w) + reset)
+			break
+		}
+		body = append(body, fit(l, w))
+	}
+	if len(t.message) > 0 {
+		body = append(body, "")
+	}
+	body = append(body, t.chart(w)...)
+	body = append(body, "")
+	body = append(body, t.recent(w)...)
+
+	// the footer goes at the very bottom, with the body cut off above it
+	room := max(0, h - len(rows) - 1)
+	if len(body) > room {
+		body = body[:room]
+	}
+	for len(body) < room {
+		body = append(body, "")
+	}
+	rows = append(rows, body...)
+	rows = append(rows, dim + fit("Enter: answer   Tab: help   Ctrl-U: clear   Ctrl-C: stop", w) + reset)
+
+	if t.help {
+		t.overlayHelp(rows, w)
+	}
+	if len(rows) > h {
+		rows = rows[:h]
+	}
+	return rows, min(inputRow, len(rows) - 1), cursorCol
+}
+
+func (t *TUI) header(now time.Time, w int) string {
+	left := fmt.Sprintf(" morseudar  %s  %d wpm", t.mode, t.wpm)
+	if t.farn != 0 {
+		left += fmt.Sprintf(" (Farnsworth %d)", t.farn)
+	}
+	left += fmt.Sprintf("  %.0f Hz", t.freq)
+
+	right := ""
+	if t.status.Num > 0 {
+		st := t.status
+		if !t.deadline.IsZero() {
+			st.Left = max(0, t.deadline.Sub(now))
+		}
+		right = st.String() + " "
+	}
+	pad := w - len([]rune(left)) - len([]rune(right))
+	if pad < 1 {
+		return reverse + bold + fit(left, w) + reset
+	}
+	return reverse + bold + left + strings.Repeat(" ", pad) + right + reset
+}
+
+// progress is the bar showing how far along sending the line is.
+func (t *TUI) progress(now time.Time, w int) string {
+	if t.sendStart.IsZero() {
+		return "Waiting to start..."
+	}
+	elapsed := min(now.Sub(t.sendStart), t.sendDur)
+	label := "Sending "
+	if elapsed >= t.sendDur {
+		label = "Sent    "
+	}
+	times := fmt.Sprintf(" %4.1fs / %.1fs", elapsed.Seconds(), t.sendDur.Seconds())
+	barLen := min(50, w - len(label) - len(times) - 2)
+	if barLen < 5 {
+		return fit(label + times, w)
+	}
+	return label + "[" + bar(elapsed, t.sendDur, barLen) + "]" + times
+}
+
+func bar(done time.Duration, total time.Duration, n int) string {
+	filled := n
+	if total > 0 {
+		filled = int(int64(n) * int64(done) / int64(total))
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", n - filled)
+}
+
+// inputLine shows what's been typed so far, scrolled so the end of it's
+// always showing, and where the cursor is on it.
+func (t *TUI) inputLine(w int) (string, int) {
+	room := max(1, w - len(prompt) - 1)
+	in := t.input
+	if len(in) > room {
+		in = in[len(in) - room:]
+	}
+	return bold + prompt + reset + string(in), len(prompt) + len(in)
+}
+
+// chart sparklines the accuracy and speed of the answers that fit.
+func (t *TUI) chart(w int) []string {
+	const label = 10
+	if len(t.history) == 0 {
+		return []string{"Accuracy  no answers yet", "Speed     no answers yet"}
+	}
+	n := max(1, min(len(t.history), w - label - 30))
+	hist := t.history[len(t.history) - n:]
+
+	acc := make([]float64, n)
+	speed := make([]float64, n)
+	var total float64
+	lo, hi := hist[0].Wpm, hist[0].Wpm
+	for i, ans := range hist {
+		acc[i] = ans.Percentage
+		speed[i] = float64(ans.Wpm)
+		total += ans.Percentage
+		lo, hi = min(lo, ans.Wpm), max(hi, ans.Wpm)
+	}
+	last := hist[n - 1]
+
+	accLine := fmt.Sprintf("%-*s%s  last %.0f%%  avg %.0f%%", label, "Accuracy", sparkline(acc, 0, 1), last.Percentage * 100, total / float64(n) * 100)
+	speedRange := fmt.Sprintf("%d", lo)
+	if lo != hi {
+		speedRange = fmt.Sprintf("%d-%d", lo, hi)
+	}
+	speedLine := fmt.Sprintf("%-*s%s  last %d wpm  range %s", label, "Speed", sparkline(speed, float64(lo) - 1, float64(hi)), last.Wpm, speedRange)
+	return []string{fit(accLine, w), fit(speedLine, w)}
+}
+
+// sparkline draws the values, from lo to hi, as a row of little bars.
+func sparkline(vals []float64, lo float64, hi float64) string {
+	sl := make([]rune, len(vals))
+	for i, v := range vals {
+		lvl := len(sparks) - 1
+		if hi > lo {
+			lvl = int((v - lo) / (hi - lo) * float64(len(sparks) - 1) + 0.5)
+		}
+		sl[i] = sparks[max(0, min(len(sparks) - 1, lvl))]
+	}
+	return string(sl)
+}
+
+// recent lists the latest answers, newest first, with the characters missed
+// from the original and the ones copied wrong marked.
+func (t *TUI) recent(w int) []string {
+	rows := []string{bold + "Recent answers" + reset}
+	if len(t.history) == 0 {
+		return append(rows, dim + "Nothing yet." + reset)
+	}
+	// percentage, then the original and the copy side by side
+	const percLen = 6
+	half := max(4, (w - percLen - 3) / 2)
+	for i := len(t.history) - 1; i >= 0; i-- {
+		ans := t.history[i]
+		perc := fmt.Sprintf("%4.0f%% ", ans.Percentage * 100)
+		switch {
+		case ans.Skipped:
+			perc = dim + "skip  " + reset
+		case ans.Percentage >= 0.9:
+			perc = green + perc + reset
+		case ans.Percentage >= 0.7:
+			perc = yellow + perc + reset
+		default:
+			perc = red + perc + reset
+		}
+		orig, resp := markDiff(ans.Original, ans.Response, half)
+		rows = append(rows, perc + orig + " | " + resp)
+	}
+	return rows
+}
+
+// markDiff lines up the copy with the original, and marks the characters of
+// the original that were missed and the ones in the copy that were wrong. Each
+// is cut down and padded to n characters.
+func markDiff(orig string, resp string, n int) (string, string) {
+	o := []rune(strings.ToLower(orig))
+	r := []rune(strings.ToLower(resp))
+	return mark(o, compare.AlignChars(o, r), red + underline, n), mark(r, compare.AlignChars(r, o), yellow + underline, n)
+}
+
+func mark(rs []rune, ok []bool, style string, n int) string {
+	cut := len(rs) > n
+	if cut {
+		rs = rs[:n - 1]
+	}
+	var sb strings.Builder
+	for i, r := range rs {
+		if !ok[i] && r != ' ' {
+			sb.WriteString(style + string(r) + reset)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	if cut {
+		sb.WriteString("…")
+	} else {
+		sb.WriteString(strings.Repeat(" ", n - len(rs)))
+	}
+	return sb.String()
+}
+
+// overlayHelp draws the help in a box over the middle of the screen.
+func (t *TUI) overlayHelp(rows []string, w int) {
+	lines := []string{bold + "Commands" + reset, "Type these instead of an answer."}
+	lines = append(lines, strings.Split(session.CommandHelp(), "\n")...)
+	lines = append(lines, "", bold + "Keys" + reset)
+	lines = append(lines, strings.Split(keyHelp(), "\n")...)
+
+	boxW := 0
+	for _, l := range lines {
+		boxW = max(boxW, visibleLen(l))
+	}
+	boxW = min(boxW + 4, w)
+	left := max(0, (w - boxW) / 2)
+	pad := strings.Repeat(" ", left)
+
+	box := make([]string, 0, len(lines) + 2)
+	box = append(box, pad + "┌" + strings.Repeat("─", boxW - 2) + "┐")
+	for _, l := range lines {
+		if visibleLen(l) > boxW - 4 {
+			l = fit(l, boxW - 4)
+		}
+		box = append(box, pad + "│ " + l + strings.Repeat(" ", boxW - 4 - visibleLen(l)) + " │")
+	}
+	box = append(box, pad + "└" + strings.Repeat("─", boxW - 2) + "┘")
+
+	// below the header, or as far down as it'll go
+	top := max(1, min(2, len(rows) - len(box)))
+	for i, l := range box {
+		if top + i >= len(rows) {
+			break
+		}
+		rows[top + i] = l
+	}
+}
+
+func keyHelp() string {
+	var sb strings.Builder
+	tw := new(tabwriter.Writer)
+	tw.Init(&sb, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "Tab, F1\tShow this help. Any key gets rid of it.")
+	fmt.Fprintln(tw, "Ctrl-U\tClear what's been typed.")
+	fmt.Fprintln(tw, "Ctrl-D\tStop taking answers and end the session.")
+	fmt.Fprintln(tw, "Ctrl-C\tStop right away, saving what's been done.")
+	tw.Flush()
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// visibleLen is how many characters of s show up on the screen, leaving out
+// escape codes.
+func visibleLen(s string) int {
+	n := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case inEscape:
+			inEscape = r < 0x40 || r > 0x7e || r == '['
+		case r == 0x1b:
+			inEscape = true
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// fit cuts plain text down to n characters, if it needs it.
+func fit(s string, n int) string {
+	rs := []rune(s)
+	if len(rs) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+	return string(rs[:n - 1]) + "…"
+}
@@ -0,0 +1,382 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tui is a full screen terminal front end for practice sessions. It's
+// a session Input and Output, drawn with plain ANSI escape codes, showing
+// where the session's at, how far along the line being sent is, what's being
+// typed, a running chart of accuracy and speed, and the recent answers with
+// their mistakes marked.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"github.com/ctdk/morseudar/internal/session"
+	"github.com/ctdk/morseudar/internal/stats"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// how many answers to hang on to for the chart and history
+const maxHistory = 200
+
+// how many answers can be typed ahead while a line's still being sent
+const typeAhead = 8
+
+// how often the progress bar and time left are redrawn
+const tick = 100 * time.Millisecond
+
+// TUI is the full screen interface. Make one with New, set it as the session's
+// Input and Output and its hooks to the ones from Hooks, then Start it before
+// running the session and Close it afterwards.
+type TUI struct {
+	// Interrupt is called when Ctrl-C is pressed, since with the terminal
+	// in raw mode it doesn't send a signal.
+	Interrupt func()
+
+	in io.Reader
+	out io.Writer
+	oldState *term.State
+	answers chan string
+	inputClosed bool
+	done chan struct{}
+	closeOnce sync.Once
+
+	mu sync.Mutex
+	mode morse.MorseMode
+	wpm int
+	farn int
+	freq float64
+	status session.Status
+	deadline time.Time
+	sendStart time.Time
+	sendDur time.Duration
+	input []rune
+	message []string
+	history []compare.Answer
+	help bool
+	ended bool
+	reason session.EndReason
+	summary *stats.Summary
+	// the size of the screen when out isn't a terminal
+	width int
+	height int
+}
+
+// New makes a TUI that reads keys from in and draws on out, starting off with
+// the Morse object's settings. Nothing's drawn until it's started.
+func New(in io.Reader, out io.Writer, m *morse.Morse) *TUI {
+	t := &TUI{
+		in: in,
+		out: out,
+		answers: make(chan string, typeAhead),
+		done: make(chan struct{}),
+		mode: m.Mode,
+		wpm: m.WPM,
+		farn: m.Farnsworth,
+		freq: m.Frequency,
+		width: 80,
+		height: 24,
+	}
+	return t
+}
+
+// Start puts the terminal in raw mode, switches to the alternate screen, and
+// starts reading keys and drawing. It needs in and out to both be terminals.
+func (t *TUI) Start() error {
+	inFd, ok := fd(t.in)
+	if !ok || !term.IsTerminal(inFd) {
+		return errors.New("the full screen interface needs to be run in a terminal")
+	}
+	if outFd, ok := fd(t.out); !ok || !term.IsTerminal(outFd) {
+		return errors.New("the full screen interface needs to be run in a terminal")
+	}
+
+	var err error
+	if t.oldState, err = term.MakeRaw(inFd); err != nil {
+		return err
+	}
+	fmt.Fprint(t.out, altScreen)
+
+	go t.readKeys()
+	go t.ticker()
+	t.redraw()
+	return nil
+}
+
+// Close puts the terminal back the way it was, then prints why the session
+// ended and the summary, if it was saved, like the plain text output would.
+// It's safe to call more than once.
+func (t *TUI) Close() {
+	t.closeOnce.Do(func() {
+		close(t.done)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.oldState != nil {
+			fmt.Fprint(t.out, mainScreen)
+			inFd, _ := fd(t.in)
+			term.Restore(inFd, t.oldState)
+		}
+		if t.ended {
+			fmt.Fprintln(t.out, t.reason)
+		}
+		if t.summary != nil {
+			fmt.Fprintln(t.out, t.summary)
+		}
+	})
+}
+
+func fd(f any) (int, bool) {
+	if file, ok := f.(*os.File); ok {
+		return int(file.Fd()), true
+	}
+	return 0, false
+}
+
+// Hooks returns the session hooks the TUI needs to keep track of what's being
+// sent, the answers, and speed changes.
+func (t *TUI) Hooks() session.Hooks {
+	return session.Hooks{Sending: t.sending, Answered: t.answered, SettingsChanged: t.settingsChanged}
+}
+
+func (t *TUI) Answers() <-chan string {
+	return t.answers
+}
+
+func (t *TUI) Line(st session.Status) {
+	t.update(func() {
+		t.status = st
+		t.deadline = time.Time{}
+		if st.Timed {
+			t.deadline = time.Now().Add(st.Left)
+		}
+	})
+}
+
+func (t *TUI) Prompt() {}
+
+func (t *TUI) Scored(ans compare.Answer) {
+	t.update(func() {
+		t.message = []string{fmt.Sprintf("'%s' was %.2f%% correct. Took %d tries over %s.", ans.Response, ans.Percentage * 100, ans.Tries, ans.Took.Round(time.Second / 100))}
+	})
+}
+
+func (t *TUI) Say(text string) {
+	t.update(func() {
+		t.message = strings.Split(text, "\n")
+	})
+}
+
+func (t *TUI) Ended(reason session.EndReason) {
+	t.update(func() {
+		t.ended = true
+		t.reason = reason
+		t.message = []string{reason.String(), "Saving and exiting..."}
+	})
+}
+
+func (t *TUI) Saved(sum stats.Summary) {
+	t.update(func() {
+		t.summary = &sum
+	})
+}
+
+func (t *TUI) sending(st session.Status, ml morsestrings.MorseString, dur time.Duration) {
+	t.update(func() {
+		t.sendStart = time.Now()
+		t.sendDur = dur
+	})
+}
+
+func (t *TUI) answered(ans compare.Answer) {
+	t.update(func() {
+		t.history = append(t.history, ans)
+		if len(t.history) > maxHistory {
+			t.history = t.history[len(t.history) - maxHistory:]
+		}
+	})
+}
+
+func (t *TUI) settingsChanged(wpm int, farn int, freq float64) {
+	t.update(func() {
+		t.wpm, t.farn, t.freq = wpm, farn, freq
+	})
+}
+
+// update changes the state and redraws the screen.
+func (t *TUI) update(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f()
+	t.draw()
+}
+
+func (t *TUI) redraw() {
+	t.update(func() {})
+}
+
+// ticker keeps the progress bar and the time left moving.
+func (t *TUI) ticker() {
+	tk := time.NewTicker(tick)
+	defer tk.Stop()
+	for {
+		select {
+		case <-tk.C:
+			t.mu.Lock()
+			if t.sendingNow(time.Now()) || !t.deadline.IsZero() {
+				t.draw()
+			}
+			t.mu.Unlock()
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// sendingNow is whether a line's being sent, give or take a tick so the
+// progress bar gets drawn full.
+func (t *TUI) sendingNow(now time.Time) bool {
+	return !t.sendStart.IsZero() && now.Sub(t.sendStart) < t.sendDur + 2 * tick
+}
+
+// the keys that do something other than type
+const (
+	keyCtrlC = 0x03
+	keyCtrlD = 0x04
+	keyBackspace = 0x08
+	keyTab = 0x09
+	keyCtrlL = 0x0c
+	keyEnter = 0x0d
+	keyCtrlU = 0x15
+	keyEscape = 0x1b
+	keyDelete = 0x7f
+	// not a real key, but F1 comes in as an escape sequence
+	keyF1 = -1
+)
+
+// readKeys reads keys until the input runs out, handling each as it comes.
+func (t *TUI) readKeys() {
+	br := bufio.NewReader(t.in)
+	for {
+		r, _, err := br.ReadRune()
+		if err != nil {
+			t.update(t.closeInput)
+			return
+		}
+		if r == keyEscape {
+			r = escape(br)
+		}
+		t.update(func() { t.key(r) })
+	}
+}
+
+// escape reads the rest of an escape sequence. The only one that matters is
+// F1; everything else besides a plain Escape is ignored.
+func escape(br *bufio.Reader) rune {
+	if br.Buffered() == 0 {
+		return keyEscape
+	}
+	b, _ := br.ReadByte()
+	if b != '[' && b != 'O' {
+		return 0
+	}
+	seq := []byte{b}
+	for br.Buffered() > 0 {
+		c, _ := br.ReadByte()
+		seq = append(seq, c)
+		if c >= 0x40 && c <= 0x7e && len(seq) > 1 {
+			break
+		}
+	}
+	switch string(seq) {
+	case "OP", "[11~", "[[A":
+		return keyF1
+	}
+	return 0
+}
+
+// key handles one key. It's called with the lock held.
+func (t *TUI) key(r rune) {
+	// any key gets rid of the help
+	if t.help {
+		t.help = false
+		return
+	}
+
+	switch r {
+	case keyCtrlC:
+		if t.Interrupt != nil {
+			// this can end up back here through Ended
+			go t.Interrupt()
+		}
+	case keyCtrlD:
+		if len(t.input) == 0 {
+			t.closeInput()
+		}
+	case keyTab, keyF1:
+		t.help = true
+	case keyEnter:
+		t.submit()
+	case keyBackspace, keyDelete:
+		if len(t.input) > 0 {
+			t.input = t.input[:len(t.input) - 1]
+		}
+	case keyCtrlU:
+		t.input = nil
+	case keyCtrlL:
+		// redrawn anyway
+	default:
+		if unicode.IsPrint(r) {
+			t.input = append(t.input, r)
+		}
+	}
+}
+
+// submit sends off whatever's been typed, unless it's asking for help, which
+// is shown here rather than by the session.
+func (t *TUI) submit() {
+	line := string(t.input)
+	if strings.ToLower(strings.TrimSpace(line)) == "`help" {
+		t.input = nil
+		t.help = true
+		return
+	}
+	if t.inputClosed {
+		return
+	}
+	select {
+	case t.answers <- line + "\n":
+		t.input = nil
+	default:
+		t.message = []string{"Hold on, there are already answers waiting to be scored."}
+	}
+}
+
+func (t *TUI) closeInput() {
+	if !t.inputClosed {
+		t.inputClosed = true
+		close(t.answers)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tui
+
+import (
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"github.com/ctdk/morseudar/internal/session"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var escapes = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]`)
+
+func newTUI(t *testing.T) *TUI {
+	t.Helper()
+	m, err := morse.New(morse.TopWords, 20, 10, 600, false, false, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(strings.NewReader(""), new(strings.Builder), m)
+}
+
+func plainScreen(ui *TUI, now time.Time, w int, h int) []string {
+	rows, _, _ := ui.screen(now, w, h)
+	for i, r := range rows {
+		rows[i] = escapes.ReplaceAllString(r, "")
+	}
+	return rows
+}
+
+func TestScreen(t *testing.T) {
+	ui := newTUI(t)
+	now := time.Now()
+	ui.Line(session.Status{Num: 3, Of: 10})
+	ui.sending(session.Status{Num: 3, Of: 10}, morsestrings.StringToMorse("hello world"), 4 * time.Second)
+	ui.sendStart = now.Add(-time.Second)
+	ui.answered(compare.Answer{Original: "the cat", Response: "the hat", Percentage: 6.0 / 7.0, Wpm: 18})
+	ui.answered(compare.Answer{Original: "sat", Skipped: true, Wpm: 20})
+	ui.settingsChanged(22, 10, 600)
+	ui.Say("Now sending at 22 wpm.")
+
+	rows := plainScreen(ui, now, 80, 24)
+	if len(rows) != 24 {
+		t.Fatalf("screen should have filled all 24 rows, had %d", len(rows))
+	}
+	screen := strings.Join(rows, "\n")
+	for _, want := range []string{"TopWords  22 wpm (Farnsworth 10)  600 Hz", "# 3 of 10", "Sending [", " 1.0s / 4.0s", "Now sending at 22 wpm.", "last 0%  avg 43%", "last 20 wpm  range 18-20", "skip  sat", "86% the cat"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("screen should have had '%s', but it was:\n%s", want, screen)
+		}
+	}
+	for _, r := range rows {
+		if n := len([]rune(r)); n > 80 {
+			t.Errorf("row '%s' is %d characters wide", r, n)
+		}
+	}
+	// a quarter of the way through
+	if !strings.Contains(screen, "["+strings.Repeat("█", 12)+"░") {
+		t.Errorf("progress bar should have been a quarter full:\n%s", screen)
+	}
+	// the newest answer's first
+	if strings.Index(screen, "skip  sat") > strings.Index(screen, "the cat") {
+		t.Errorf("recent answers should be newest first:\n%s", screen)
+	}
+
+	// still has to fit when it's tiny
+	if rows = plainScreen(ui, now, 30, 8); len(rows) != 8 {
+		t.Errorf("small screen should have had 8 rows, had %d", len(rows))
+	}
+}
+
+func TestKeys(t *testing.T) {
+	ui := newTUI(t)
+	for _, r := range "hellp" {
+		ui.key(r)
+	}
+	ui.key(keyDelete)
+	ui.key('o')
+	ui.key(keyEnter)
+	if got := <-ui.Answers(); got != "hello\n" {
+		t.Errorf("expected 'hello' to be entered, got '%s'", got)
+	}
+
+	// `help is handled here instead of being passed along
+	for _, r := range "`help" {
+		ui.key(r)
+	}
+	ui.key(keyEnter)
+	if !ui.help || len(ui.Answers()) != 0 {
+		t.Error("`help should have shown the help, and not been entered")
+	}
+	rows := plainScreen(ui, time.Now(), 100, 40)
+	if !strings.Contains(strings.Join(rows, "\n"), "│ `skip") {
+		t.Error("help should have been on the screen")
+	}
+	// any key gets rid of it, and doesn't get typed
+	ui.key('x')
+	if ui.help || len(ui.input) != 0 {
+		t.Error("a key should have just hidden the help")
+	}
+
+	interrupted := make(chan struct{})
+	ui.Interrupt = func() { close(interrupted) }
+	ui.key(keyCtrlC)
+	select {
+	case <-interrupted:
+	case <-time.After(time.Second):
+		t.Error("Ctrl-C should have interrupted the session")
+	}
+
+	ui.key(keyCtrlD)
+	if _, ok := <-ui.Answers(); ok {
+		t.Error("Ctrl-D should have closed the input")
+	}
+}
+
+func TestMarkDiff(t *testing.T) {
+	orig, resp := markDiff("the cat", "the hat sat", 12)
+	if o := escapes.ReplaceAllString(orig, ""); o != "the cat     " {
+		t.Errorf("original should have been padded out, got '%s'", o)
+	}
+	if !strings.Contains(orig, underline + "c" + reset) {
+		t.Errorf("the missed 'c' should have been marked in '%q'", orig)
+	}
+	if !strings.Contains(resp, underline + "h" + reset) || strings.Contains(resp, underline + "t" + reset + "h") {
+		t.Errorf("only the wrong characters should have been marked in '%q'", resp)
+	}
+
+	orig, _ = markDiff("a very long line indeed", "", 10)
+	if o := escapes.ReplaceAllString(orig, ""); o != "a very lo…" {
+		t.Errorf("long original should have been cut off, got '%s'", o)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{0, 0.5, 1}, 0, 1); got != "▁▅█" {
+		t.Errorf("expected '▁▅█', got '%s'", got)
+	}
+	if got := sparkline([]float64{20, 20}, 20, 20); got != "██" {
+		t.Errorf("expected a flat line, got '%s'", got)
+	}
+}
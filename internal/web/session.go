@@ -0,0 +1,344 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"errors"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/morsestrings"
+	"github.com/ctdk/morseudar/internal/session"
+	"github.com/ctdk/morseudar/internal/stats"
+	"io"
+	"sync"
+	"time"
+)
+
+// sessionInfo is how a session's doing.
+type sessionInfo struct {
+	ID string `json:"id"`
+	Started time.Time `json:"started"`
+	Mode string `json:"mode"`
+	Wpm int `json:"wpm"`
+	Farnsworth int `json:"farnsworth"`
+	Frequency float64 `json:"frequency"`
+	Lines int `json:"lines,omitempty"`
+	Answered int `json:"answered"`
+}
+
+// lineInfo is a line waiting to be copied: everything the browser needs to
+// play it, but not what it says.
+type lineInfo struct {
+	Num int `json:"num"`
+	Of int `json:"of,omitempty"`
+	Wpm int `json:"wpm"`
+	Farnsworth int `json:"farnsworth"`
+	Frequency float64 `json:"frequency"`
+	// how long the whole line takes, space after it and all
+	DurationMs float64 `json:"duration_ms"`
+	Elements []element `json:"elements"`
+	// Text is only filled in when it's asked for, since it's usually
+	// what's being copied.
+	Text string `json:"text,omitempty"`
+	ml morsestrings.MorseString
+}
+
+// withText returns a copy of the line with the text filled in.
+func (li *lineInfo) withText() *lineInfo {
+	if li == nil {
+		return nil
+	}
+	cp := *li
+	cp.Text = li.ml.RawString()
+	return &cp
+}
+
+// element is one beep or silence. Times are in milliseconds, since that's
+// what's handiest for JavaScript.
+type element struct {
+	On bool `json:"on"`
+	Ms float64 `json:"ms"`
+}
+
+// answerReply is what happened after an answer or command was sent in.
+type answerReply struct {
+	// Answer is the scored answer, if it was one, or the skipped line.
+	Answer *compare.Answer `json:"answer,omitempty"`
+	// Messages are what the session had to say, like hints and what
+	// commands print.
+	Messages []string `json:"messages,omitempty"`
+	// Line is set if a line was sent: the next one, or the same one
+	// again after `replay or a speed change.
+	Line *lineInfo `json:"line,omitempty"`
+	// Ended is why the session ended, if it did, and Summary is what was
+	// saved.
+	Ended string `json:"ended,omitempty"`
+	Summary *stats.JSONSummary `json:"summary,omitempty"`
+}
+
+// webSession runs a session engine in the background, taking one answer at a
+// time from requests and collecting what the engine does with each into a
+// reply.
+type webSession struct {
+	id string
+	engine *session.Engine
+	input chan string
+	// an answer's handled, start to finish, before the next is taken
+	reqMu sync.Mutex
+	// signalled when the engine wants an answer or has finished
+	ready chan struct{}
+
+	mu sync.Mutex
+	line *lineInfo
+	reply *answerReply
+	count int
+	ended bool
+	err error
+	started time.Time
+	lastUsed time.Time
+}
+
+func startSession(id string, m *morse.Morse, comp *compare.Comparator, lines int, uStats *stats.UserStats, statsLock sync.Locker) (*webSession, error) {
+	ws := &webSession{id: id, input: make(chan string), ready: make(chan struct{}, 1), started: time.Now()}
+	ws.lastUsed = ws.started
+	e := session.New(m, comp, inputChan(ws.input), (*sessionOutput)(ws))
+	e.Lines = lines
+	e.Stats = uStats
+	e.StatsLock = statsLock
+	e.Hooks = session.Hooks{Sending: ws.sending, Answered: ws.answered, Ended: ws.finished}
+	ws.engine = e
+
+	// the first line's reply has nowhere to go
+	ws.reply = new(answerReply)
+	go func() {
+		if _, err := e.Run(); err != nil {
+			ws.mu.Lock()
+			ws.err = err
+			ws.ended = true
+			ws.mu.Unlock()
+			ws.signal()
+		}
+	}()
+	<-ws.ready
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.reply = nil
+	if ws.err != nil {
+		return nil, ws.err
+	}
+	return ws, nil
+}
+
+// submit hands an answer or command to the session and waits until the
+// session's ready for another, or it's over.
+func (ws *webSession) submit(answer string) (*answerReply, error) {
+	ws.reqMu.Lock()
+	defer ws.reqMu.Unlock()
+
+	ws.mu.Lock()
+	if ws.ended {
+		ws.mu.Unlock()
+		return nil, errSessionOver
+	}
+	ws.reply = new(answerReply)
+	ws.lastUsed = time.Now()
+	ws.mu.Unlock()
+
+	ws.input <- answer
+	<-ws.ready
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	reply := ws.reply
+	ws.reply = nil
+	return reply, ws.err
+}
+
+// quit ends the session, saving it.
+func (ws *webSession) quit() (*answerReply, error) {
+	return ws.submit("`quit")
+}
+
+func (ws *webSession) signal() {
+	select {
+	case ws.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (ws *webSession) info() sessionInfo {
+	// the settings only change while the engine's handling an answer
+	ws.reqMu.Lock()
+	defer ws.reqMu.Unlock()
+	m := ws.engine.Morse
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return sessionInfo{ID: ws.id, Started: ws.started, Mode: m.Mode.Name(), Wpm: m.WPM, Farnsworth: m.Farnsworth, Frequency: m.Frequency, Lines: ws.engine.Lines, Answered: ws.count}
+}
+
+func (ws *webSession) currentLine() *lineInfo {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.lastUsed = time.Now()
+	if ws.ended {
+		return nil
+	}
+	return ws.line
+}
+
+// wav renders the current line as a WAV file, at the session's current
+// settings.
+func (ws *webSession) wav(w io.Writer) error {
+	ws.reqMu.Lock()
+	defer ws.reqMu.Unlock()
+	li := ws.currentLine()
+	if li == nil {
+		return errSessionOver
+	}
+	mf := new(memFile)
+	if err := ws.engine.Morse.Render(mf, [][]morsestrings.MorseString{{li.ml}}); err != nil {
+		return err
+	}
+	_, err := w.Write(mf.buf)
+	return err
+}
+
+func (ws *webSession) idleSince(now time.Time) time.Duration {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return now.Sub(ws.lastUsed)
+}
+
+// the hooks, which are called from the engine's goroutine
+
+func (ws *webSession) sending(st session.Status, ml morsestrings.MorseString, dur time.Duration) {
+	m := ws.engine.Morse
+	els, _ := m.Elements(ml)
+	li := &lineInfo{Num: st.Num, Of: st.Of, Wpm: m.WPM, Farnsworth: m.Farnsworth, Frequency: m.Frequency, DurationMs: ms(dur), Elements: make([]element, len(els)), ml: ml}
+	for i, e := range els {
+		li.Elements[i] = element{On: e.On, Ms: ms(e.Dur)}
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.line = li
+	if ws.reply != nil {
+		ws.reply.Line = li
+	}
+}
+
+func (ws *webSession) answered(ans compare.Answer) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.count++
+	if ws.reply != nil {
+		ws.reply.Answer = &ans
+	}
+}
+
+func (ws *webSession) finished(res *session.Result) {
+	ws.mu.Lock()
+	ws.ended = true
+	ws.line = nil
+	ws.mu.Unlock()
+	ws.signal()
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// memFile is somewhere in memory to render a WAV file to, since rendering
+// needs to seek back and fill in the header at the end.
+type memFile struct {
+	buf []byte
+	pos int
+}
+
+func (mf *memFile) Write(p []byte) (int, error) {
+	if end := mf.pos + len(p); end > len(mf.buf) {
+		mf.buf = append(mf.buf, make([]byte, end - len(mf.buf))...)
+	}
+	n := copy(mf.buf[mf.pos:], p)
+	mf.pos += n
+	return n, nil
+}
+
+func (mf *memFile) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = int64(mf.pos) + offset
+	case io.SeekEnd:
+		pos = int64(len(mf.buf)) + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+	mf.pos = int(pos)
+	return pos, nil
+}
+
+type inputChan chan string
+
+func (ic inputChan) Answers() <-chan string {
+	return ic
+}
+
+// sessionOutput is the session's Output, which puts everything into the reply
+// for the answer being handled.
+type sessionOutput webSession
+
+func (so *sessionOutput) Line(st session.Status) {}
+
+// Prompt means the session's ready for the next answer.
+func (so *sessionOutput) Prompt() {
+	(*webSession)(so).signal()
+}
+
+// Scored doesn't need to do anything, since the Answered hook has it.
+func (so *sessionOutput) Scored(ans compare.Answer) {}
+
+func (so *sessionOutput) Say(text string) {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	if so.reply != nil {
+		so.reply.Messages = append(so.reply.Messages, text)
+	}
+}
+
+func (so *sessionOutput) Ended(reason session.EndReason) {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	if so.reply != nil {
+		so.reply.Ended = reason.String()
+	}
+}
+
+func (so *sessionOutput) Saved(sum stats.Summary) {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	if so.reply != nil {
+		jsum := stats.NewJSONSummary(sum)
+		so.reply.Summary = &jsum
+	}
+}
@@ -0,0 +1,236 @@
+// The browser end of morseudar serve. The server runs the session and works
+// out the timing of each beep; this plays them with WebAudio and sends back
+// what was copied.
+"use strict";
+
+const $ = (id) => document.getElementById(id);
+
+let audio = null;
+let session = null;
+let playing = null;
+
+// how long each beep takes to fade in and out, to keep it from clicking
+const ramp = 0.005;
+
+function play(line) {
+	if (playing) {
+		playing.stop();
+	}
+	if (!audio) {
+		audio = new AudioContext();
+	}
+	const osc = audio.createOscillator();
+	const gain = audio.createGain();
+	osc.frequency.value = line.frequency;
+	gain.gain.value = 0;
+	osc.connect(gain).connect(audio.destination);
+
+	const start = audio.currentTime + 0.1;
+	let t = start;
+	for (const el of line.elements) {
+		const dur = el.ms / 1000;
+		if (el.on) {
+			gain.gain.setValueAtTime(0, t);
+			gain.gain.linearRampToValueAtTime(1, t + ramp);
+			gain.gain.setValueAtTime(1, t + dur - ramp);
+			gain.gain.linearRampToValueAtTime(0, t + dur);
+		}
+		t += dur;
+	}
+	osc.start(start);
+	osc.stop(t);
+	playing = osc;
+
+	const total = t - start;
+	const bar = $("progress");
+	const tick = () => {
+		if (playing !== osc) {
+			return;
+		}
+		bar.value = Math.min(1, Math.max(0, (audio.currentTime - start) / total));
+		if (bar.value < 1) {
+			requestAnimationFrame(tick);
+		}
+	};
+	tick();
+	showStatus(line);
+	$("answer").focus();
+}
+
+function showStatus(line) {
+	let s = "Line " + line.num;
+	if (line.of) {
+		s += " of " + line.of;
+	}
+	s += " at " + line.wpm + " wpm";
+	if (line.farnsworth) {
+		s += " (Farnsworth " + line.farnsworth + ")";
+	}
+	$("status").textContent = s + ", " + Math.round(line.frequency) + " Hz";
+}
+
+async function api(method, path, body) {
+	const opts = { method: method, headers: {} };
+	if (body !== undefined) {
+		opts.headers["Content-Type"] = "application/json";
+		opts.body = JSON.stringify(body);
+	}
+	const resp = await fetch(path, opts);
+	const data = await resp.json();
+	if (!resp.ok) {
+		throw new Error(data.error || resp.statusText);
+	}
+	return data;
+}
+
+function say(text) {
+	$("messages").textContent = text;
+}
+
+async function start(ev) {
+	ev.preventDefault();
+	const form = new FormData($("setup"));
+	const settings = {};
+	for (const [k, v] of form.entries()) {
+		if (v === "") {
+			continue;
+		}
+		settings[k] = k === "mode" ? v : parseInt(v, 10);
+	}
+	// the audio has to be started from a click
+	if (!audio) {
+		audio = new AudioContext();
+	}
+	try {
+		if (session) {
+			await api("DELETE", "/api/sessions/" + session.id);
+		}
+		session = await api("POST", "/api/sessions", settings);
+		$("answers").replaceChildren();
+		$("practice").hidden = false;
+		say("");
+		play(await api("GET", "/api/sessions/" + session.id + "/line"));
+	} catch (err) {
+		say(err.message);
+	}
+}
+
+async function send(answer) {
+	if (!session) {
+		return;
+	}
+	try {
+		const reply = await api("POST", "/api/sessions/" + session.id + "/answers", { answer: answer });
+		handle(reply);
+	} catch (err) {
+		say(err.message);
+	}
+}
+
+function handle(reply) {
+	say((reply.messages || []).join("\n"));
+	if (reply.answer) {
+		addAnswer(reply.answer);
+	}
+	if (reply.ended) {
+		if (playing) {
+			playing.stop();
+		}
+		session = null;
+		$("progress").value = 0;
+		say([reply.ended].concat(reply.messages || []).join("\n"));
+		loadStats();
+		return;
+	}
+	if (reply.line) {
+		play(reply.line);
+	}
+}
+
+// marked lines the copy up with what was sent, marking the characters that
+// were missed. It's a plain longest common subsequence, which is close enough
+// for showing.
+function marked(sent, copied) {
+	const a = sent.toLowerCase(), b = copied.toLowerCase();
+	const n = a.length, m = b.length;
+	const lcs = [];
+	for (let i = 0; i <= n; i++) {
+		lcs.push(new Array(m + 1).fill(0));
+	}
+	for (let i = n - 1; i >= 0; i--) {
+		for (let j = m - 1; j >= 0; j--) {
+			lcs[i][j] = a[i] === b[j] ? lcs[i + 1][j + 1] + 1 : Math.max(lcs[i + 1][j], lcs[i][j + 1]);
+		}
+	}
+	const frag = document.createDocumentFragment();
+	let i = 0, j = 0;
+	while (i < n) {
+		if (j < m && a[i] === b[j]) {
+			frag.append(a[i]);
+			i++;
+			j++;
+		} else if (j < m && lcs[i][j + 1] >= lcs[i + 1][j]) {
+			j++;
+		} else {
+			const miss = document.createElement("span");
+			miss.className = "miss";
+			miss.textContent = a[i];
+			frag.append(miss);
+			i++;
+		}
+	}
+	return frag;
+}
+
+function addAnswer(ans) {
+	const tr = document.createElement("tr");
+	const sent = document.createElement("td");
+	sent.append(marked(ans.original, ans.response));
+	const copied = document.createElement("td");
+	copied.textContent = ans.skipped ? "(skipped)" : ans.response;
+	const perc = document.createElement("td");
+	perc.className = "num " + (ans.percentage >= 0.9 ? "good" : ans.percentage >= 0.7 ? "okay" : "bad");
+	perc.textContent = (ans.percentage * 100).toFixed(0) + "%";
+	const wpm = document.createElement("td");
+	wpm.className = "num";
+	wpm.textContent = ans.wpm;
+	tr.append(sent, copied, perc, wpm);
+	$("answers").prepend(tr);
+}
+
+async function loadStats() {
+	let st;
+	try {
+		st = await api("GET", "/api/stats");
+	} catch (err) {
+		$("progress-text").textContent = err.message;
+		return;
+	}
+	$("progress-text").textContent = st.progress;
+	const rows = (st.summaries || []).slice(-20).reverse().map((s) => {
+		const tr = document.createElement("tr");
+		const cells = [new Date(s.date).toLocaleString(), s.mode, s.count, (s.avg_correct * 100).toFixed(1) + "%", s.wpm];
+		for (const c of cells) {
+			const td = document.createElement("td");
+			td.textContent = c;
+			tr.append(td);
+		}
+		return tr;
+	});
+	$("stats").replaceChildren(...rows);
+}
+
+$("setup").addEventListener("submit", start);
+$("answer").addEventListener("keydown", (ev) => {
+	if (ev.key !== "Enter") {
+		return;
+	}
+	const answer = ev.target.value;
+	ev.target.value = "";
+	send(answer);
+});
+$("replay").addEventListener("click", () => send("`replay"));
+$("skip").addEventListener("click", () => send("`skip"));
+$("hint").addEventListener("click", () => send("`hint"));
+$("stop").addEventListener("click", () => send("`quit"));
+loadStats();
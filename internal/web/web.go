@@ -0,0 +1,445 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package web serves practice sessions over HTTP, to a page in the browser
+// that plays the Morse code itself with WebAudio. Sessions are run by the same
+// session engine as practicing in a terminal, with the Morse sent silently on
+// this end and the timing of each beep handed over to the browser instead.
+package web
+
+import (
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/stats"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed static
+var static embed.FS
+
+// sessions left alone this long are ended and saved the next time a session's
+// started
+const idleTimeout = time.Hour
+
+// SessionSettings are what a session's asked to be started with. Anything
+// left out is up to the Setup.
+type SessionSettings struct {
+	Mode string `json:"mode,omitempty"`
+	Wpm int `json:"wpm,omitempty"`
+	Farnsworth int `json:"farnsworth,omitempty"`
+	Frequency int `json:"frequency,omitempty"`
+	// Lines ends the session after that many lines, if it's set.
+	Lines int `json:"lines,omitempty"`
+}
+
+// Setup makes the Morse object, with its testing material, and the comparator
+// for a new session. The Morse object needs to be silent, since the browser's
+// doing the playing.
+type Setup func(ss SessionSettings) (*morse.Morse, *compare.Comparator, error)
+
+// Server serves the page and the sessions it runs, and the API they're run
+// with. The README describes the API.
+type Server struct {
+	// NoUI turns off the page, leaving just the API.
+	NoUI bool
+	// AllowOrigins are the origins other than the server's own whose pages
+	// can use the API, for front ends served from somewhere else. "*"
+	// allows any of them.
+	AllowOrigins []string
+
+	setup Setup
+	stats *stats.UserStats
+	statsLock sync.Mutex
+	mu sync.Mutex
+	sessions map[string]*webSession
+	mux *http.ServeMux
+}
+
+// New makes a Server that starts sessions with setup and saves them to
+// uStats. uStats may be nil, in which case nothing's saved.
+func New(setup Setup, uStats *stats.UserStats) *Server {
+	s := &Server{setup: setup, stats: uStats, sessions: make(map[string]*webSession), mux: http.NewServeMux()}
+
+	files, _ := fs.Sub(static, "static")
+	s.mux.Handle("/", http.FileServer(http.FS(files)))
+	s.mux.HandleFunc("/api/sessions", s.handleSessions)
+	s.mux.HandleFunc("/api/sessions/", s.handleSession)
+	s.mux.HandleFunc("/api/stats", s.handleStats)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api := strings.HasPrefix(r.URL.Path, "/api/")
+	if !api && s.NoUI {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if api && s.cors(w, r) {
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// cors adds the CORS headers for allowed origins, and answers preflight
+// requests. It returns true if the request's been taken care of.
+func (s *Server) cors(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || !s.allowed(origin) {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Add("Vary", "Origin")
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+	return false
+}
+
+func (s *Server) allowed(origin string) bool {
+	for _, o := range s.AllowOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close ends every session that's still going, saving them.
+func (s *Server) Close() {
+	s.mu.Lock()
+	sessions := make([]*webSession, 0, len(s.sessions))
+	for id, ws := range s.sessions {
+		sessions = append(sessions, ws)
+		delete(s.sessions, id)
+	}
+	s.mu.Unlock()
+
+	for _, ws := range sessions {
+		ws.quit()
+	}
+}
+
+// GET /api/sessions lists the sessions, and POST /api/sessions starts one.
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listSessions(w)
+		return
+	case http.MethodPost:
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		return
+	}
+	var ss SessionSettings
+	if err := json.NewDecoder(r.Body).Decode(&ss); err != nil {
+		writeError(w, http.StatusBadRequest, "couldn't read the session settings: " + err.Error())
+		return
+	}
+	if ss.Lines < 0 {
+		writeError(w, http.StatusBadRequest, "the number of lines can't be negative")
+		return
+	}
+	m, comp, err := s.setup(ss)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.endIdle(time.Now())
+
+	id, err := newID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ws, err := startSession(id, m, comp, ss.Lines, s.stats, &s.statsLock)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.mu.Lock()
+	s.sessions[id] = ws
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, ws.info())
+}
+
+func (s *Server) listSessions(w http.ResponseWriter) {
+	s.mu.Lock()
+	sessions := make([]*webSession, 0, len(s.sessions))
+	for _, ws := range s.sessions {
+		sessions = append(sessions, ws)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].started.Before(sessions[j].started)
+	})
+	infos := make([]sessionInfo, len(sessions))
+	for i, ws := range sessions {
+		infos[i] = ws.info()
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// /api/sessions/{id} and below
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/sessions/"), "/")
+	s.mu.Lock()
+	ws, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such session")
+		return
+	}
+
+	switch rest {
+	case "":
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, ws.info())
+		case http.MethodDelete:
+			s.mu.Lock()
+			delete(s.sessions, id)
+			s.mu.Unlock()
+			reply, err := ws.quit()
+			if err != nil {
+				writeError(w, http.StatusConflict, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, reply)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+	case "line":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		withText, err := boolParam(r, "text")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch format := r.URL.Query().Get("format"); format {
+		case "", "json":
+			li := ws.currentLine()
+			if li == nil {
+				writeError(w, http.StatusConflict, errSessionOver.Error())
+				return
+			}
+			if withText {
+				li = li.withText()
+			}
+			writeJSON(w, http.StatusOK, li)
+		case "wav":
+			// rendered first, so an error can still be sent as one
+			var buf bytes.Buffer
+			if err := ws.wav(&buf); err != nil {
+				writeError(w, http.StatusConflict, err.Error())
+				return
+			}
+			w.Header().Set("Content-Type", "audio/wav")
+			w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+			w.Write(buf.Bytes())
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown format '%s': it should be json or wav", format))
+		}
+	case "answers":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		withText, err := boolParam(r, "text")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var body struct {
+			Answer string `json:"answer"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "couldn't read the answer: " + err.Error())
+			return
+		}
+		reply, err := ws.submit(body.Answer)
+		if err != nil {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if reply.Ended != "" {
+			s.mu.Lock()
+			delete(s.sessions, id)
+			s.mu.Unlock()
+		}
+		if withText {
+			reply.Line = reply.Line.withText()
+		}
+		writeJSON(w, http.StatusOK, reply)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// GET /api/stats returns the saved sessions, and how the streak and goals are
+// going. The sessions can be narrowed down by mode and date, and their
+// answers are left out unless they're asked for.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if s.stats == nil {
+		writeError(w, http.StatusNotFound, "no statistics are being kept")
+		return
+	}
+
+	q := r.URL.Query()
+	var mode *morse.MorseMode
+	if name := q.Get("mode"); name != "" {
+		mm, err := morse.ModeFromName(name)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		mode = &mm
+	}
+	var since time.Time
+	if sinceStr := q.Get("since"); sinceStr != "" {
+		var err error
+		if since, err = parseTime(sinceStr); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	withAnswers, err := boolParam(r, "answers")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.statsLock.Lock()
+	defer s.statsLock.Unlock()
+	if withAnswers {
+		if err = s.stats.LoadAnswers(); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	var progress strings.Builder
+	s.stats.WriteProgress(&progress, time.Now(), false)
+	sums := make([]stats.JSONSummary, 0, len(s.stats.Summaries))
+	for _, sum := range s.stats.Summaries {
+		if (mode != nil && sum.Mode != *mode) || sum.Date.Before(since) {
+			continue
+		}
+		jsum := stats.NewJSONSummary(sum)
+		// the same mode names the sessions are started with, rather
+		// than the ones exports use
+		jsum.Mode = sum.Mode.Name()
+		if !withAnswers {
+			// the answers can add up
+			jsum.Answers = nil
+		}
+		sums = append(sums, jsum)
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Username string `json:"username"`
+		Summaries []stats.JSONSummary `json:"summaries"`
+		Progress string `json:"progress"`
+	}{s.stats.Username, sums, progress.String()})
+}
+
+// endIdle ends and saves the sessions nobody's touched in a while.
+func (s *Server) endIdle(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, ws := range s.sessions {
+		if ws.idleSince(now) > idleTimeout {
+			delete(s.sessions, id)
+			go ws.quit()
+		}
+	}
+}
+
+// boolParam reads a true or false query parameter, which is false if it isn't
+// there.
+func boolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("'%s' should be true or false, not '%s'", name, v)
+	}
+	return b, nil
+}
+
+// parseTime takes a date like 2025-06-01, in local time, or a full RFC 3339
+// time.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' should be a date like 2025-06-01 or an RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{msg})
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+var errSessionOver = errors.New("the session's over")
@@ -0,0 +1,314 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/stats"
+	"github.com/ctdk/morseudar/internal/wordlists"
+	"github.com/gopxl/beep/wav"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSetup(ss SessionSettings) (*morse.Morse, *compare.Comparator, error) {
+	m, err := morse.New(morse.MorseChar, ss.Wpm, ss.Farnsworth, float64(ss.Frequency), false, false, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.Silent = true
+	m.TestingMaterial = wordlists.GetChars(m.Src())
+	return m, compare.New(), nil
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *Server, *stats.UserStats) {
+	t.Helper()
+	u, err := stats.Load(filepath.Join(t.TempDir(), "stats"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(testSetup, u)
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return ts, s, u
+}
+
+// call makes a request and decodes the JSON that comes back into v, if it's
+// given.
+func call(t *testing.T, ts *httptest.Server, method string, path string, body any, want int, v any) {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, ts.URL + path, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s should have been %d, was %d: %s", method, path, want, resp.StatusCode, b)
+	}
+	if v != nil {
+		if err = json.Unmarshal(b, v); err != nil {
+			t.Fatalf("%s %s didn't return JSON: %s", method, path, err)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	ts, _, _ := newTestServer(t)
+	for _, p := range []string{"/", "/app.js"} {
+		resp, err := ts.Client().Get(ts.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(b), "morseudar") {
+			t.Errorf("%s should have been served, got %d", p, resp.StatusCode)
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	ts, _, u := newTestServer(t)
+
+	var info sessionInfo
+	call(t, ts, http.MethodPost, "/api/sessions", SessionSettings{Wpm: 20, Lines: 2}, http.StatusCreated, &info)
+	if info.ID == "" || info.Mode != "chars" || info.Wpm != 20 || info.Lines != 2 {
+		t.Fatalf("unexpected session %+v", info)
+	}
+	base := "/api/sessions/" + info.ID
+
+	var li lineInfo
+	call(t, ts, http.MethodGet, base + "/line", nil, http.StatusOK, &li)
+	if li.Num != 1 || li.Of != 2 || len(li.Elements) == 0 || li.Frequency != 700 {
+		t.Fatalf("unexpected line %+v", li)
+	}
+	var total float64
+	for _, e := range li.Elements {
+		total += e.Ms
+	}
+	if total != li.DurationMs {
+		t.Errorf("the elements add up to %fms, but the line's supposed to be %fms", total, li.DurationMs)
+	}
+	// at 20 wpm, a dit's 60ms
+	if first := li.Elements[0]; !first.On || (first.Ms != 60 && first.Ms != 180) {
+		t.Errorf("the first element should have been a dit or dah at 20 wpm, got %+v", first)
+	}
+
+	var reply answerReply
+	call(t, ts, http.MethodPost, base + "/answers", map[string]string{"answer": "`wpm 25"}, http.StatusOK, &reply)
+	if reply.Answer != nil || reply.Line == nil || reply.Line.Num != 1 || reply.Line.Wpm != 25 {
+		t.Errorf("changing the speed should have sent the same line again at 25 wpm, got %+v", reply)
+	}
+
+	reply = answerReply{}
+	call(t, ts, http.MethodPost, base + "/answers", map[string]string{"answer": "e"}, http.StatusOK, &reply)
+	if reply.Answer == nil || reply.Answer.Response != "e" || reply.Answer.Tries != 2 || reply.Answer.Wpm != 25 {
+		t.Fatalf("expected the answer to be scored, got %+v", reply)
+	}
+	if reply.Line == nil || reply.Line.Num != 2 {
+		t.Errorf("the next line should have been sent, got %+v", reply.Line)
+	}
+
+	reply = answerReply{}
+	call(t, ts, http.MethodPost, base + "/answers", map[string]string{"answer": "`skip"}, http.StatusOK, &reply)
+	if reply.Ended == "" || reply.Summary == nil || reply.Summary.Count != 2 {
+		t.Fatalf("the session should have ended after 2 lines and been saved, got %+v", reply)
+	}
+	if len(u.Summaries) != 1 {
+		t.Errorf("the session should have been saved to the stats")
+	}
+	call(t, ts, http.MethodGet, base + "/line", nil, http.StatusNotFound, nil)
+
+	var st struct {
+		Summaries []stats.JSONSummary
+	}
+	call(t, ts, http.MethodGet, "/api/stats", nil, http.StatusOK, &st)
+	if len(st.Summaries) != 1 || st.Summaries[0].Count != 2 || st.Summaries[0].Mode != "chars" {
+		t.Errorf("unexpected stats %+v", st)
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	ts, s, u := newTestServer(t)
+
+	call(t, ts, http.MethodPut, "/api/sessions", nil, http.StatusMethodNotAllowed, nil)
+	call(t, ts, http.MethodPost, "/api/sessions", "not settings", http.StatusBadRequest, nil)
+	call(t, ts, http.MethodPost, "/api/sessions", SessionSettings{Lines: -1}, http.StatusBadRequest, nil)
+	call(t, ts, http.MethodGet, "/api/sessions/nope/line", nil, http.StatusNotFound, nil)
+
+	var info sessionInfo
+	call(t, ts, http.MethodPost, "/api/sessions", SessionSettings{}, http.StatusCreated, &info)
+	call(t, ts, http.MethodGet, "/api/sessions/" + info.ID + "/bogus", nil, http.StatusNotFound, nil)
+	call(t, ts, http.MethodPost, "/api/sessions/" + info.ID + "/line", nil, http.StatusMethodNotAllowed, nil)
+	call(t, ts, http.MethodGet, "/api/sessions/" + info.ID + "/line?format=mp3", nil, http.StatusBadRequest, nil)
+	call(t, ts, http.MethodGet, "/api/sessions/" + info.ID + "/line?text=maybe", nil, http.StatusBadRequest, nil)
+	call(t, ts, http.MethodGet, "/api/stats?mode=bogus", nil, http.StatusBadRequest, nil)
+	// the names exports use aren't the API's
+	call(t, ts, http.MethodGet, "/api/stats?mode=MorseChar", nil, http.StatusBadRequest, nil)
+	call(t, ts, http.MethodGet, "/api/stats?since=yesterday", nil, http.StatusBadRequest, nil)
+
+	// sessions still going are saved when the server's closed, as long as
+	// something was answered
+	call(t, ts, http.MethodGet, "/api/sessions/" + info.ID + "/line", nil, http.StatusOK, nil)
+	call(t, ts, http.MethodPost, "/api/sessions/" + info.ID + "/answers", map[string]string{"answer": "e"}, http.StatusOK, nil)
+	call(t, ts, http.MethodPost, "/api/sessions", SessionSettings{}, http.StatusCreated, &info)
+	s.Close()
+	if len(u.Summaries) != 1 {
+		t.Errorf("closing the server should have saved the answered session and only that one, saved %d", len(u.Summaries))
+	}
+}
+
+func TestAPI(t *testing.T) {
+	ts, _, u := newTestServer(t)
+
+	var first, second sessionInfo
+	call(t, ts, http.MethodPost, "/api/sessions", SessionSettings{Wpm: 20, Lines: 1}, http.StatusCreated, &first)
+	call(t, ts, http.MethodPost, "/api/sessions", SessionSettings{Wpm: 15}, http.StatusCreated, &second)
+	var list []sessionInfo
+	call(t, ts, http.MethodGet, "/api/sessions", nil, http.StatusOK, &list)
+	if len(list) != 2 || list[0].ID != first.ID || list[1].ID != second.ID {
+		t.Fatalf("expected both sessions, oldest first, got %+v", list)
+	}
+	base := "/api/sessions/" + first.ID
+
+	var li lineInfo
+	call(t, ts, http.MethodGet, base + "/line", nil, http.StatusOK, &li)
+	if li.Text != "" {
+		t.Errorf("the text shouldn't have been given away without asking, got '%s'", li.Text)
+	}
+	call(t, ts, http.MethodGet, base + "/line?text=true", nil, http.StatusOK, &li)
+	if li.Text == "" || len(li.Elements) == 0 {
+		t.Fatalf("expected the text and the timing, got %+v", li)
+	}
+
+	resp, err := ts.Client().Get(ts.URL + base + "/line?format=wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "audio/wav" {
+		t.Fatalf("expected a WAV file, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	streamer, format, err := wav.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("the WAV file didn't decode: %s", err)
+	}
+	// the WAV's the line and the space after it, like the timing
+	wavMs := format.SampleRate.D(streamer.Len()).Seconds() * 1000
+	if math.Abs(wavMs - li.DurationMs) > 1 {
+		t.Errorf("the WAV is %fms long, but the line's %fms", wavMs, li.DurationMs)
+	}
+
+	var reply answerReply
+	call(t, ts, http.MethodPost, base + "/answers?text=true", map[string]string{"answer": li.Text}, http.StatusOK, &reply)
+	if reply.Answer == nil || reply.Answer.Percentage != 1 || reply.Ended == "" {
+		t.Fatalf("expected a perfect answer ending the session, got %+v", reply)
+	}
+	call(t, ts, http.MethodGet, base + "/line?format=wav", nil, http.StatusNotFound, nil)
+	call(t, ts, http.MethodGet, "/api/sessions", nil, http.StatusOK, &list)
+	if len(list) != 1 || list[0].ID != second.ID {
+		t.Errorf("only the second session should be left, got %+v", list)
+	}
+
+	var st struct {
+		Summaries []stats.JSONSummary
+	}
+	call(t, ts, http.MethodGet, "/api/stats?answers=true", nil, http.StatusOK, &st)
+	if len(st.Summaries) != 1 || len(st.Summaries[0].Answers) != 1 || st.Summaries[0].Answers[0].Original != li.Text {
+		t.Fatalf("expected the session with its answer, got %+v", st)
+	}
+	call(t, ts, http.MethodGet, "/api/stats?mode=codegroups", nil, http.StatusOK, &st)
+	if len(st.Summaries) != 0 {
+		t.Errorf("there aren't any code group sessions, got %+v", st.Summaries)
+	}
+	call(t, ts, http.MethodGet, "/api/stats?mode=chars&since=2000-01-01", nil, http.StatusOK, &st)
+	if len(st.Summaries) != 1 || st.Summaries[0].Answers != nil {
+		t.Errorf("expected the session without its answers, got %+v", st.Summaries)
+	}
+	tomorrow := u.Summaries[0].Date.Add(24 * time.Hour).Format(time.RFC3339)
+	call(t, ts, http.MethodGet, "/api/stats?since=" + url.QueryEscape(tomorrow), nil, http.StatusOK, &st)
+	if len(st.Summaries) != 0 {
+		t.Errorf("there aren't any sessions since %s, got %+v", tomorrow, st.Summaries)
+	}
+}
+
+func TestAPIOptions(t *testing.T) {
+	ts, s, _ := newTestServer(t)
+	s.NoUI = true
+	s.AllowOrigins = []string{"http://localhost:3000"}
+
+	resp, err := ts.Client().Get(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("the page shouldn't be served with NoUI, got %d", resp.StatusCode)
+	}
+
+	tests := []struct {
+		origin string
+		allowed bool
+	}{
+		{"http://localhost:3000", true},
+		{"http://example.com", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodOptions, ts.URL + "/api/sessions", nil)
+		req.Header.Set("Origin", tt.origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		got := resp.Header.Get("Access-Control-Allow-Origin")
+		if tt.allowed && (resp.StatusCode != http.StatusNoContent || got != tt.origin) {
+			t.Errorf("%s should have been allowed, got %d '%s'", tt.origin, resp.StatusCode, got)
+		}
+		if !tt.allowed && got != "" {
+			t.Errorf("%s shouldn't have been allowed", tt.origin)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+/*
+ * Copyright (c) 2019-2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ctdk/morseudar/internal/codegroups"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/morserrors"
+	"github.com/ctdk/morseudar/internal/session"
+	"github.com/ctdk/morseudar/internal/stats"
+	"github.com/ctdk/morseudar/internal/textblock"
+	"github.com/ctdk/morseudar/internal/tui"
+	"github.com/ctdk/morseudar/internal/wordlists"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// PracticeSettings are the settings that can be saved with a profile or in the
+// config file, as well as given when practicing. The numbers are pointers so
+// that giving 0, like -o 0 to turn Farnsworth timing off, can be told apart
+// from not giving them at all.
+type PracticeSettings struct {
+	Wpm *int `short:"w" long:"wpm" description:"Words per minute. Defaults to 10."`
+	Farnsworth *int `short:"o" long:"farnsworth" description:"Farnsworth timing. Words are sent at the speed given with -w/--wpm, but the spaces between words are sent at this WPM. For instance, -w 20 -o 10 would send words at 20 wpm, but spaced out as if they were sent at 10 wpm, giving you more time to process."`
+	Frequency *int `short:"f" long:"frequency" description:"Frequency in Hz for Morse beep. Defaults to 700."`
+	Mode string `short:"m" long:"mode" description:"Mode to practice in. Options include: text (requires -t/--text), randomline (the same as text; both send lines at random unless -r/--sequential is given), codegroups, codealnum, codenumbers, topwords, qcodes, chars. Defaults to topwords, or text if -t/--text is given."`
+	TopWordNum *int `short:"n" long:"top-word-num" description:"How many words from the top word list to include. Only relevant in topwords mode."`
+}
+
+// PracticeMaterial are the options for what gets sent, which go with anything
+// that runs practice sessions.
+type PracticeMaterial struct {
+	Text string `short:"t" long:"text" description:"Path to text file to load and use for copying testing. Required for 'text' mode."`
+	Qquestions bool `short:"q" long:"qcode-questions" description:"Include Q codes followed by a question mark (i.e. QRS and QRS?)."`
+	Seq bool `short:"r" long:"sequential" description:"Send lines sequentially instead of randomly. Not relevant for the code group modes."`
+}
+
+type PracticeCommand struct {
+	PracticeSettings
+	PracticeMaterial
+	EntireBlock bool `short:"b" long:"entire-block" description:"Send the entire block of text at once, rather than line by line, and score the whole transcription afterwards. Unsurprisingly, only relevant for -t/--text."`
+	StartLine int `long:"start-line" description:"First line of the text file to send with -b/--entire-block. Blank lines count, but aren't sent. Defaults to the first line."`
+	EndLine int `long:"end-line" description:"Last line of the text file to send with -b/--entire-block. Blank lines count, but aren't sent. Defaults to the last line."`
+	Adaptive string `short:"a" long:"adaptive" description:"Adjust the speed between lines to keep your accuracy in the --target range. 'wpm' changes the character speed, while 'farnsworth' leaves that alone and changes the spacing instead." choice:"wpm" choice:"farnsworth"`
+	Target string `long:"target" description:"Target accuracy range for -a/--adaptive, in percent." default:"85-95"`
+	Lines int `long:"lines" description:"End the session after this many lines, then print the summary and save the statistics."`
+	Duration time.Duration `long:"duration" description:"End the session after this long, like 5m or 1h30m, then print the summary and save the statistics. Time's up even in the middle of a line."`
+	TUI bool `long:"tui" description:"Practice in a full screen terminal interface, showing the progress of each line as it's sent, a chart of your accuracy and speed, and your recent answers with the mistakes marked. Not for -b/--entire-block."`
+}
+
+// runPractice runs a practice session until it's over one way or another,
+// then saves the statistics and exits.
+func runPractice(uStats *stats.UserStats, pc *PracticeCommand) {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	settings, err := resolveSettings(&pc.PracticeSettings, pc.Text, uStats.Settings, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mode := settings.Mode
+
+	// make a morse object!
+
+	m, err := morse.New(mode, settings.Wpm, settings.Farnsworth, float64(settings.Frequency), pc.Seq, pc.EntireBlock, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = m.SetEffects(settings.Effects); err != nil {
+		log.Fatal(err)
+	}
+
+	if pc.Adaptive != "" {
+		low, high, err := parseTarget(pc.Target)
+		if err != nil {
+			log.Fatalf("Invalid --target '%s': it should look like '85-95'.", pc.Target)
+		}
+		m.Adapter, err = morse.NewBandAdapter(low, high, pc.Adaptive == "farnsworth")
+		if err != nil {
+			log.Fatalf("Invalid --target '%s': %s", pc.Target, err)
+		}
+	}
+
+	if err = setMaterial(m, pc.Text, pc.Qquestions, settings.TopWordNum); err != nil {
+		log.Fatal(err)
+	}
+
+	// a little encouragement before starting
+	uStats.WriteProgress(os.Stdout, time.Now(), false)
+
+	comp, err := compare.NewWithCosts(settings.Costs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var in session.Input = session.NewReaderInput(os.Stdin)
+	var out session.Output = session.NewTextOutput(os.Stdout)
+	var ui *tui.TUI
+	if pc.TUI {
+		if m.EntireBlock {
+			log.Fatal("The full screen interface can't be used with -b/--entire-block. Exiting.")
+		}
+		ui = tui.New(os.Stdin, os.Stdout, m)
+		in, out = ui, ui
+	}
+	e := session.New(m, comp, in, out)
+	e.Stats = uStats
+	e.Lines = pc.Lines
+	e.Duration = pc.Duration
+	catchSignals(e)
+	if ui != nil {
+		e.Hooks = ui.Hooks()
+		ui.Interrupt = e.Interrupt
+		if err = ui.Start(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var res *session.Result
+	if m.EntireBlock {
+		if mode != morse.TextFile {
+			log.Fatal("Sending the entire block requires text mode. Exiting.")
+		}
+		res, err = e.RunBlock(pc.StartLine, pc.EndLine)
+	} else {
+		if pc.Lines > 0 || pc.Duration > 0 {
+			out.Say(sessionLimits(pc.Lines, pc.Duration))
+		}
+		res, err = e.Run()
+	}
+	if ui != nil {
+		ui.Close()
+	}
+	m.Close()
+	if err != nil {
+		if res != nil {
+			log.Fatal("Unable to save statistics: ", err)
+		}
+		log.Fatal(err)
+	}
+	os.Exit(0)
+}
+
+// setMaterial attaches the Stone of Triumph, or rather the testing material
+// for the Morse object's mode.
+func setMaterial(m *morse.Morse, text string, qquestions bool, topWordNum int) error {
+	switch m.Mode {
+	case morse.CodeGroup:
+		// set up proper length options later
+		m.TestingMaterial = codegroups.NewCodegroup(m.Src(), codegroups.Alpha, 0, 0)
+	case morse.CodeAlnum:
+		// set up proper length options later
+		m.TestingMaterial = codegroups.NewCodegroup(m.Src(), codegroups.Alnum, 0, 0)
+	case morse.CodeNum:
+		// set up proper length options later
+		m.TestingMaterial = codegroups.NewCodegroup(m.Src(), codegroups.Num, 0, 0)
+	case morse.TopWords:
+		m.TestingMaterial = wordlists.GetTopWords(topWordNum, m.Src())
+	case morse.Qcode:
+		m.TestingMaterial = wordlists.GetQCodes(qquestions, m.Src())
+	case morse.MorseChar:
+		m.TestingMaterial = wordlists.GetChars(m.Src())
+	case morse.TextFile:
+		// die if we're in text mode but weren't given a text file to
+		// load.
+		if text == "" {
+			return errors.New("Text mode requires the -t/--text argument and a text file. Exiting.")
+		}
+
+		tb := textblock.NewTextblock(m.Src())
+		if err := tb.LoadFile(text); err != nil {
+			return fmt.Errorf("Unable to load text file: %w", err)
+		}
+		m.TestingMaterial = tb
+	}
+	return nil
+}
+
+func sessionLimits(lines int, dur time.Duration) string {
+	switch {
+	case lines > 0 && dur > 0:
+		return fmt.Sprintf("This session ends after %d lines or %s, whichever comes first.", lines, dur)
+	case lines > 0:
+		return fmt.Sprintf("This session ends after %d lines.", lines)
+	default:
+		return fmt.Sprintf("This session ends after %s.", dur)
+	}
+}
+
+// parseTarget turns a target accuracy range like "85-95" into fractions.
+func parseTarget(target string) (float64, float64, error) {
+	lowStr, highStr, ok := strings.Cut(target, "-")
+	if !ok {
+		return 0, 0, morserrors.InvalidValue
+	}
+	low, err := strconv.ParseFloat(strings.TrimSpace(lowStr), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	high, err := strconv.ParseFloat(strings.TrimSpace(highStr), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return low / 100, high / 100, nil
+}
+
+// catchSignals interrupts the session as soon as there's a SIGINT or SIGTERM,
+// cutting off any Morse being sent, so the session can be wrapped up properly.
+// Only the first signal is caught, so if wrapping up gets stuck a second
+// Ctrl-C still gets out.
+func catchSignals(e *session.Engine) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		signal.Stop(sigs)
+		e.Interrupt()
+	}()
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/stats"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+type ProfileCommand struct {
+	List ProfileListCommand `command:"list" description:"List the profiles."`
+	Create ProfileCreateCommand `command:"create" description:"Create a new profile, optionally with its own default settings."`
+	Set ProfileSetCommand `command:"set" description:"Change a profile's default settings."`
+	Delete ProfileDeleteCommand `command:"delete" description:"Delete a profile and all of its statistics."`
+}
+
+type ProfileListCommand struct {}
+
+type profileName struct {
+	Name string `positional-arg-name:"NAME" description:"Name of the profile."`
+}
+
+type ProfileCreateCommand struct {
+	PracticeSettings
+	Args profileName `positional-args:"yes" required:"yes"`
+}
+
+type ProfileSetCommand struct {
+	PracticeSettings
+	Args profileName `positional-args:"yes" required:"yes"`
+}
+
+type ProfileDeleteCommand struct {
+	Yes bool `short:"y" long:"yes" description:"Don't ask before deleting the profile."`
+	Args profileName `positional-args:"yes" required:"yes"`
+}
+
+func runProfileCommand(name string, pc *ProfileCommand) error {
+	switch name {
+	case "list":
+		return listProfiles()
+	case "create":
+		settings, err := pc.Create.settings()
+		if err != nil {
+			return err
+		}
+		if _, err := stats.CreateProfile(pc.Create.Args.Name, settings); err != nil {
+			return err
+		}
+		fmt.Printf("Created profile '%s'. Use it with --profile=%s.\n", pc.Create.Args.Name, pc.Create.Args.Name)
+	case "set":
+		return setProfile(pc.Set.Args.Name, &pc.Set.PracticeSettings)
+	case "delete":
+		return deleteProfile(pc.Delete.Args.Name, pc.Delete.Yes)
+	}
+	return nil
+}
+
+func (ps *PracticeSettings) settings() (stats.Settings, error) {
+	mode := strings.ToLower(ps.Mode)
+	if mode != "" {
+		if _, err := morse.ModeFromName(mode); err != nil {
+			return stats.Settings{}, err
+		}
+	}
+	return stats.Settings{Wpm: intOrZero(ps.Wpm), Farnsworth: intOrZero(ps.Farnsworth), Frequency: intOrZero(ps.Frequency), Mode: mode, TopWordNum: intOrZero(ps.TopWordNum)}, nil
+}
+
+func intOrZero(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func listProfiles() error {
+	names, err := stats.ListProfiles()
+	if err != nil {
+		return err
+	}
+
+	tw := new(tabwriter.Writer)
+	tw.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "Profile\tSessions\tLast practiced\tSettings")
+	for _, n := range names {
+		u, err := stats.LoadProfile(n)
+		if err != nil {
+			fmt.Fprintf(tw, "%s\t-\t-\tunable to load: %s\n", n, err)
+			continue
+		}
+		last := "never"
+		if len(u.Summaries) > 0 {
+			last = u.Updated.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", n, len(u.Summaries), last, describeSettings(u.Settings))
+	}
+	return tw.Flush()
+}
+
+// setProfile only changes the settings that were given, leaving the rest as
+// they were. Giving 0 unsets one.
+func setProfile(name string, ps *PracticeSettings) error {
+	u, err := stats.LoadProfile(name)
+	if err != nil {
+		return profileError(name, err)
+	}
+
+	s, err := ps.settings()
+	if err != nil {
+		return err
+	}
+	set := u.Settings
+	if ps.Wpm != nil {
+		set.Wpm = s.Wpm
+	}
+	if ps.Farnsworth != nil {
+		set.Farnsworth = s.Farnsworth
+	}
+	if ps.Frequency != nil {
+		set.Frequency = s.Frequency
+	}
+	if s.Mode != "" {
+		set.Mode = s.Mode
+	}
+	if ps.TopWordNum != nil {
+		set.TopWordNum = s.TopWordNum
+	}
+	u.SetSettings(set)
+
+	if err = u.Save(u.SavePath()); err != nil {
+		return err
+	}
+	fmt.Printf("Settings for '%s': %s\n", name, describeSettings(u.Settings))
+	return nil
+}
+
+func deleteProfile(name string, yes bool) error {
+	if !yes {
+		fmt.Printf("Really delete profile '%s' and all of its statistics? [y/N] ", name)
+		resp, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if r := strings.ToLower(strings.TrimSpace(resp)); r != "y" && r != "yes" {
+			fmt.Println("Not deleting.")
+			return nil
+		}
+	}
+	if err := stats.DeleteProfile(name); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted profile '%s'.\n", name)
+	return nil
+}
+
+func describeSettings(s stats.Settings) string {
+	parts := make([]string, 0, 5)
+	if s.Mode != "" {
+		parts = append(parts, "mode " + s.Mode)
+	}
+	if s.Wpm != 0 {
+		parts = append(parts, fmt.Sprintf("%d wpm", s.Wpm))
+	}
+	if s.Farnsworth != 0 {
+		parts = append(parts, fmt.Sprintf("farnsworth %d", s.Farnsworth))
+	}
+	if s.Frequency != 0 {
+		parts = append(parts, fmt.Sprintf("%d Hz", s.Frequency))
+	}
+	if s.TopWordNum != 0 {
+		parts = append(parts, fmt.Sprintf("top %d words", s.TopWordNum))
+	}
+	if len(parts) == 0 {
+		return "defaults"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func profileError(name string, err error) error {
+	if errors.Is(err, stats.ErrNoProfile) {
+		return fmt.Errorf("%w. Create it first with 'morseudar profile create %s'.", err, name)
+	}
+	return err
+}
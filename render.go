@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"github.com/ctdk/morseudar/internal/audio"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/textblock"
+	"io"
+	"os"
+	"strings"
+)
+
+type RenderCommand struct {
+	Wpm int `short:"w" long:"wpm" description:"Words per minute. Defaults to the config file's setting, or 10."`
+	Farnsworth int `short:"o" long:"farnsworth" description:"Farnsworth timing, in WPM, for the spaces between words."`
+	Frequency int `short:"f" long:"frequency" description:"Frequency in Hz for Morse beep. Defaults to the config file's setting, or 700."`
+	Volume float64 `long:"volume" description:"Volume of the beeps, from 0 to 1. Defaults to the config file's setting, or 1."`
+	Output string `short:"O" long:"output" description:"WAV file to write." required:"yes"`
+	Text string `short:"t" long:"text" description:"Text file to render. Blank lines separate paragraphs, which get a longer pause between them."`
+	Args struct {
+		Text []string `positional-arg-name:"TEXT" description:"Text to render. If there isn't any and no -t/--text file is given, it's read from standard input."`
+	} `positional-args:"yes"`
+}
+
+func renderMorse(rc *RenderCommand) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// the command line wins over the config file, like when practicing
+	s := cfg.Settings
+	if rc.Wpm != 0 {
+		s.Wpm = rc.Wpm
+	}
+	if rc.Farnsworth != 0 {
+		s.Farnsworth = rc.Farnsworth
+	}
+	if rc.Frequency != 0 {
+		s.Frequency = rc.Frequency
+	}
+	if rc.Volume != 0 {
+		s.Audio.Volume = rc.Volume
+	}
+
+	var r io.Reader
+	switch {
+	case len(rc.Args.Text) > 0:
+		r = strings.NewReader(strings.Join(rc.Args.Text, " "))
+	case rc.Text != "":
+		f, err := os.Open(rc.Text)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	default:
+		r = os.Stdin
+	}
+
+	m, err := morse.New(morse.TextFile, s.Wpm, s.Farnsworth, float64(s.Frequency), true, true, 0)
+	if err != nil {
+		return err
+	}
+	if err = m.SetEffects(audio.Effects{Volume: s.Audio.Volume, Decay: s.Audio.Decay}); err != nil {
+		return err
+	}
+	tb := textblock.NewTextblock(m.Src())
+	if err = tb.Load(r); err != nil {
+		return err
+	}
+	m.TestingMaterial = tb
+	paras, err := m.GetBlock(0, 0)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(rc.Output)
+	if err != nil {
+		return err
+	}
+	if err = m.Render(f, paras); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	lines := 0
+	for _, p := range paras {
+		lines += len(p)
+	}
+	fmt.Printf("Rendered %d %s at %d wpm to %s.\n", lines, plural(lines, "line"), m.WPM, rc.Output)
+	return nil
+}
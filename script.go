@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/session"
+	"github.com/ctdk/morseudar/internal/stats"
+	"io"
+	"os"
+)
+
+type ScriptCommand struct {
+	PracticeSettings
+	PracticeMaterial
+	Lines int `long:"lines" description:"End the session after this many lines."`
+	Seed int64 `long:"seed" description:"Seed for choosing the lines to send. The same seed and script give the same session every time. 0 picks a different seed each time." default:"1"`
+	Args struct {
+		Script string `positional-arg-name:"SCRIPT" description:"File with the answers to give, one per line. Commands like skip and quit, starting with a backtick, work too. Standard input is read if it isn't given, or is '-'."`
+	} `positional-args:"yes"`
+}
+
+// runScript runs a whole practice session without any sound or anyone typing,
+// taking the answers from a script and writing what happens as JSON lines.
+// Nothing's saved to the statistics.
+func runScript(uStats *stats.UserStats, sc *ScriptCommand) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	settings, err := resolveSettings(&sc.PracticeSettings, sc.Text, uStats.Settings, cfg)
+	if err != nil {
+		return err
+	}
+
+	m, err := morse.New(settings.Mode, settings.Wpm, settings.Farnsworth, float64(settings.Frequency), sc.Seq, false, sc.Seed)
+	if err != nil {
+		return err
+	}
+	m.Silent = true
+	if err = m.SetEffects(settings.Effects); err != nil {
+		return err
+	}
+	if err = setMaterial(m, sc.Text, sc.Qquestions, settings.TopWordNum); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if sc.Args.Script != "" && sc.Args.Script != "-" {
+		f, err := os.Open(sc.Args.Script)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	comp, err := compare.NewWithCosts(settings.Costs)
+	if err != nil {
+		return err
+	}
+	out := session.NewJSONOutput(os.Stdout)
+	e := session.New(m, comp, session.NewReaderInput(r), out)
+	e.Hooks = out.Hooks()
+	e.Lines = sc.Lines
+	_, err = e.Run()
+	return err
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2025, Jeremy Bingham (<jeremy@goiardi.gl>)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ctdk/morseudar/internal/copy-compare"
+	"github.com/ctdk/morseudar/internal/morse"
+	"github.com/ctdk/morseudar/internal/stats"
+	"github.com/ctdk/morseudar/internal/web"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+type ServeCommand struct {
+	PracticeSettings
+	PracticeMaterial
+	Listen string `short:"l" long:"listen" description:"Address to listen on. The default only takes connections from this computer; use something like ':8073' to let other computers on the network practice." default:"localhost:8073"`
+	NoUI bool `long:"no-ui" description:"Only serve the REST API, without the practice page, for using morseudar from other programs."`
+	AllowOrigins []string `long:"allow-origin" description:"Let pages from this origin, like 'http://localhost:3000', use the API from the browser. Can be given more than once; '*' allows any origin."`
+}
+
+// serve runs the web server until it's told to stop, then saves any sessions
+// that are still going. The practice settings given are the defaults for
+// sessions started in the browser.
+func serve(uStats *stats.UserStats, sc *ServeCommand) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	setup := func(ss web.SessionSettings) (*morse.Morse, *compare.Comparator, error) {
+		ps := sc.PracticeSettings
+		if ss.Mode != "" {
+			ps.Mode = ss.Mode
+		}
+		if ss.Wpm != 0 {
+			ps.Wpm = &ss.Wpm
+		}
+		if ss.Farnsworth != 0 {
+			ps.Farnsworth = &ss.Farnsworth
+		}
+		if ss.Frequency != 0 {
+			ps.Frequency = &ss.Frequency
+		}
+		settings, err := resolveSettings(&ps, sc.Text, uStats.Settings, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if settings.Wpm < 0 || settings.Farnsworth < 0 || settings.Frequency < 0 {
+			return nil, nil, errors.New("the speed and frequency can't be negative")
+		}
+
+		// the browser does the playing
+		m, err := morse.New(settings.Mode, settings.Wpm, settings.Farnsworth, float64(settings.Frequency), sc.Seq, false, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		m.Silent = true
+		if err = setMaterial(m, sc.Text, sc.Qquestions, settings.TopWordNum); err != nil {
+			return nil, nil, err
+		}
+		comp, err := compare.NewWithCosts(settings.Costs)
+		if err != nil {
+			return nil, nil, err
+		}
+		return m, comp, nil
+	}
+
+	ws := web.New(setup, uStats)
+	ws.NoUI = sc.NoUI
+	ws.AllowOrigins = sc.AllowOrigins
+	srv := &http.Server{Addr: sc.Listen, Handler: ws, ReadHeaderTimeout: 10 * time.Second}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	stopped := make(chan struct{})
+	go func() {
+		<-sigs
+		signal.Stop(sigs)
+		log.Println("Shutting down, and saving any sessions that are still going.")
+		ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+		close(stopped)
+	}()
+
+	addr := sc.Listen
+	if host, port, err := net.SplitHostPort(addr); err == nil && (host == "" || host == "0.0.0.0" || host == "::") {
+		addr = net.JoinHostPort("localhost", port)
+	}
+	if sc.NoUI {
+		fmt.Printf("Serving the API at http://%s/api/ (Ctrl-C to stop).\n", addr)
+	} else {
+		fmt.Printf("Practice in your browser at http://%s/ (Ctrl-C to stop).\n", addr)
+	}
+	if err = srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-stopped
+	ws.Close()
+	return nil
+}